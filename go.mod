@@ -1,9 +1,10 @@
-module gotree
+module github.com/Raita876/gotree
 
 go 1.14
 
 require (
 	github.com/google/go-cmp v0.4.0
-	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/mattn/go-isatty v0.0.12
 	github.com/urfave/cli/v2 v2.2.0
+	golang.org/x/sys v0.0.0-20200116001909-b77594299b42
 )
@@ -1,16 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math"
 	"os"
-	"os/user"
-	"path/filepath"
+	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 
+	"github.com/Raita876/gotree/tree"
+	"github.com/Raita876/gotree/tui"
 	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v2"
 )
@@ -20,743 +23,15 @@ var (
 	name    string
 )
 
-const (
-	// connector
-	CONNECTOR_CROSS       = "├── "
-	CONNECTOR_RIGHT_ANGLE = "└── "
-	CONNECTOR_LINE        = "│   "
-	CONNECTOR_BLANK       = "    "
-
-	// print color
-	PRINT_COLOR_BLACK         = "\x1b[30m%s\x1b[0m"
-	PRINT_COLOR_RED           = "\x1b[31m%s\x1b[0m"
-	PRINT_COLOR_GREEN         = "\x1b[32m%s\x1b[0m"
-	PRINT_COLOR_YELLOW        = "\x1b[33m%s\x1b[0m"
-	PRINT_COLOR_BLUE          = "\x1b[34m%s\x1b[0m"
-	PRINT_COLOR_PURPLE        = "\x1b[35m%s\x1b[0m"
-	PRINT_COLOR_CYAN          = "\x1b[36m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_GRAY    = "\x1b[37m%s\x1b[0m"
-	PRINT_COLOR_DARK_GRAY     = "\x1b[90m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_RED     = "\x1b[91m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_GREEN   = "\x1b[92m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_YELLOW  = "\x1b[93m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_BLUE    = "\x1b[94m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_MAGENTA = "\x1b[95m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_CYAN    = "\x1b[96m%s\x1b[0m"
-	PRINT_COLOR_LIGHT_WHITE   = "\x1b[97m%s\x1b[0m"
-
-	// format
-	PRINT_BOLD       = "\x1b[1m%s\x1b[0m"
-	PRINT_UNDER_LINE = "\x1b[4m%s\x1b[0m"
-)
-
-func ColorRed(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_RED, s)
-}
-
-func ColorGreen(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_GREEN, s)
-}
-
-func ColorYellow(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_YELLOW, s)
-}
-
-func ColorBlue(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_BLUE, s)
-}
-
-func ColorPurple(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_PURPLE, s)
-}
-
-func ColorCyan(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_CYAN, s)
-}
-
-func ColorLightGray(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_GRAY, s)
-}
-
-func ColorDarkGray(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_DARK_GRAY, s)
-}
-
-func ColorLightRed(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_RED, s)
-}
-
-func ColorLightGreen(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_GREEN, s)
-}
-
-func ColorLightYellow(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_YELLOW, s)
-}
-
-func ColorLightBlue(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_BLUE, s)
-}
-
-func ColorLightMagenta(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_MAGENTA, s)
-}
-
-func ColorLightCyan(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_CYAN, s)
-}
-
-func ColorLightWhite(s string) string {
-	return fmt.Sprintf(PRINT_COLOR_LIGHT_WHITE, s)
-}
-
-func FormatBold(s string) string {
-	return fmt.Sprintf(PRINT_BOLD, s)
-}
-
-func FormatUnderLine(s string) string {
-	return fmt.Sprintf(PRINT_UNDER_LINE, s)
-}
-
-func FormatSize(size int64) string {
-	if size < 1000 {
-		return fmt.Sprintf("%d", size)
-	}
-
-	prefix := "kMGTP"
-	for i := 0; i < len(prefix); i++ {
-		s := int(size) / 1000 * (i + 1)
-		if s < 1000 {
-			return fmt.Sprintf("%d%s", s, string(prefix[i]))
-		}
-	}
-
-	return "?????"
-}
-
-func contains(sl []string, s string) bool {
-	for _, v := range sl {
-		if v == s {
-			return true
-		}
-	}
-
-	return false
-}
-
-func ext(fileName string) string {
-	ext := filepath.Ext(fileName)
-
-	if ext == "" {
-		return ext
-	} else {
-		return ext[1:]
-	}
-}
-
-type Walker struct {
-	dirNum     int
-	fileNum    int
-	isEndDir   []bool
-	colored    bool
-	level      uint
-	permission bool
-	uid        bool
-	gid        bool
-	size       bool
-	includeDot bool
-	datetime   bool
-}
-
-type Option interface {
-	apply(*Walker)
-}
-
-type coloredOption bool
-
-func (c coloredOption) apply(w *Walker) {
-	w.colored = bool(c)
-}
-
-type levelOption uint
-
-func (l levelOption) apply(w *Walker) {
-	w.level = uint(l)
-}
-
-type permissionOption bool
-
-func (p permissionOption) apply(w *Walker) {
-	w.permission = bool(p)
-}
-
-type uidOption bool
-
-func (u uidOption) apply(w *Walker) {
-	w.uid = bool(u)
-}
-
-type gidOption bool
-
-func (g gidOption) apply(w *Walker) {
-	w.gid = bool(g)
-}
-
-type sizeOption bool
-
-func (s sizeOption) apply(w *Walker) {
-	w.size = bool(s)
-}
-
-type includeDotOption bool
-
-func (i includeDotOption) apply(w *Walker) {
-	w.includeDot = bool(i)
-}
-
-type datetimeOption bool
-
-func (dt datetimeOption) apply(w *Walker) {
-	w.datetime = bool(dt)
-}
-
-type Row struct {
-	fileInfo     os.FileInfo
-	level        uint
-	onRightAngle bool
-	isBlank      []bool
-	colored      bool
-	permission   bool
-	uid          bool
-	gid          bool
-	size         bool
-	datetime     bool
-}
-
-func (row *Row) Status() string {
-	status := ""
-
-	if row.permission {
-		status += row.Mode() + " "
-	}
-
-	if row.uid {
-		status += row.User() + " "
-	}
-
-	if row.gid {
-		status += row.Group() + " "
-	}
-
-	if row.size {
-		status += row.Size() + " "
-	}
-
-	if row.datetime {
-		status += row.Datetime() + " "
-	}
-
-	if status != "" {
-		return fmt.Sprintf("[%s]  ", strings.TrimSpace(status))
-	}
-
-	return status
-}
-
-func (row *Row) Datetime() string {
-	mt := row.fileInfo.ModTime().Format("2006-01-02 15:04")
-
-	if row.colored {
-		mt = ColorBlue(mt)
-	}
-
-	return mt
-}
-
-func (row *Row) Size() string {
-	if row.fileInfo.IsDir() {
-		return "-"
-	}
-
-	size := row.fileInfo.Size()
-	fs := FormatSize(size)
-
-	if row.colored {
-		fs = ColorGreen(fs)
-	}
-
-	return fs
-}
-
-func (row *Row) User() string {
-	var userName string
-	var uid string
-
-	if stat, ok := row.fileInfo.Sys().(*syscall.Stat_t); ok {
-		uid = fmt.Sprintf("%d", stat.Uid)
-	} else {
-		uid = fmt.Sprintf("%d", os.Getuid())
-	}
-
-	u, err := user.LookupId(uid)
-	if err != nil {
-		userName = uid
-	} else {
-		userName = u.Username
-	}
-
-	if row.colored {
-		userName = ColorYellow(userName)
-	}
-
-	return userName
-}
-
-func (row *Row) Group() string {
-	var group string
-	var gid string
-
-	if stat, ok := row.fileInfo.Sys().(*syscall.Stat_t); ok {
-		gid = fmt.Sprintf("%d", stat.Gid)
-	} else {
-		gid = fmt.Sprintf("%d", os.Getgid())
-	}
-
-	g, err := user.LookupGroupId(gid)
-	if err != nil {
-		group = gid
-	} else {
-		group = g.Name
-	}
-
-	if row.colored {
-		group = ColorYellow(group)
-	}
-
-	return group
-}
-
-func (row *Row) Name() string {
-	name := row.fileInfo.Name()
-
-	if row.colored {
-		if row.isDir() {
-			return ColorLightBlue(name) + "/"
-		}
-
-		if row.isExec() {
-			return ColorLightGreen(name) + "*"
-		}
-
-		if row.isImmediate() {
-			return FormatUnderLine(ColorLightYellow(name))
-		}
-
-		if row.isImage() {
-			return ColorLightMagenta(name)
-		}
-
-		if row.isVideo() {
-			return ColorPurple(name)
-		}
-
-		if row.isMusic() {
-			return ColorPurple(name)
-		}
-
-		if row.isCrypto() {
-			return ColorLightCyan(name)
-		}
-
-		if row.isDocument() {
-			return ColorGreen(name)
-		}
-
-		if row.isCompressed() {
-			return ColorRed(name)
-		}
-
-		if row.isTemp() {
-			return ColorDarkGray(name)
-		}
-
-		if row.isCompiled() {
-			return ColorYellow(name)
-		}
-	}
-
-	return name
-}
-
-func (row *Row) File() string {
-	return fmt.Sprintf("%s%s", row.Status(), row.Name())
-}
-
-func (row *Row) Str() string {
-	var str string
-	for i := 0; i < int(row.level-1); i++ {
-		if row.isBlank[i] {
-			str += row.connectorBlank()
-		} else {
-			str += row.connectorLine()
-		}
-	}
-
-	if row.onRightAngle {
-		str += row.connectorRightAngle() + row.File()
-	} else {
-		str += row.connectorCross() + row.File()
-	}
-
-	return str
-}
-
-func (row *Row) Mode() string {
-	var m uint32
-	m = uint32(row.fileInfo.Mode())
-	const str = "dalTLDpSugct?"
-	var modeStr [10]string
-
-	for i, c := range str {
-		if m&(1<<uint(32-1-i)) != 0 {
-			if row.colored {
-				modeStr[0] = ColorLightBlue(string(c))
-			} else {
-				modeStr[0] = string(c)
-			}
-		}
-	}
-
-	if modeStr[0] == "" {
-		modeStr[0] = "."
-	}
-
-	w := 1
-	const rwx = "rwxrwxrwx"
-	for i, c := range rwx {
-		if m&(1<<uint(9-1-i)) != 0 {
-			if row.colored {
-				switch s := string(c); s {
-				case "r":
-					modeStr[w] = ColorYellow(string(c))
-				case "w":
-					modeStr[w] = ColorRed(string(c))
-				case "x":
-					modeStr[w] = ColorGreen(string(c))
-				}
-			} else {
-				modeStr[w] = string(c)
-			}
-		} else {
-			modeStr[w] = "-"
-		}
-		w++
-	}
-
-	return strings.Join(modeStr[:], "")
-}
-
-func (row *Row) connectorCross() string {
-	if row.colored {
-		return ColorDarkGray(CONNECTOR_CROSS)
-	}
-
-	return CONNECTOR_CROSS
-}
-
-func (row *Row) connectorLine() string {
-	if row.colored {
-		return ColorDarkGray(CONNECTOR_LINE)
-	}
-
-	return CONNECTOR_LINE
-}
-
-func (row *Row) connectorRightAngle() string {
-	if row.colored {
-		return ColorDarkGray(CONNECTOR_RIGHT_ANGLE)
-	}
-
-	return CONNECTOR_RIGHT_ANGLE
-}
-
-func (row *Row) connectorBlank() string {
-	return CONNECTOR_BLANK
-}
-
-func (row *Row) isDir() bool {
-	return row.fileInfo.IsDir()
-}
-
-func (row *Row) isExec() bool {
-	var m uint32
-	m = uint32(row.fileInfo.Mode())
-
-	const rwx = "rwxrwxrwx"
-	for i := 0; i < 9; i++ {
-		if m&(1<<uint(9-1-i)) != 0 && i%3 == 2 {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (row *Row) isImmediate() bool {
-	name := row.fileInfo.Name()
-
-	nameWithoutExt := name[:len(name)-len(filepath.Ext(name))]
-	if strings.ToLower(nameWithoutExt) == "readme" {
-		return true
-	}
-
-	immediateFiles := []string{
-		"Makefile", "Cargo.toml", "SConstruct", "CMakeLists.txt",
-		"build.gradle", "pom.xml", "Rakefile", "package.json", "Gruntfile.js",
-		"Gruntfile.coffee", "BUILD", "BUILD.bazel", "WORKSPACE", "build.xml",
-		"webpack.config.js", "meson.build",
-	}
-
-	if contains(immediateFiles, name) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isImage() bool {
-	imageExts := []string{
-		"png", "jpeg", "jpg", "gif", "bmp", "tiff", "tif",
-		"ppm", "pgm", "pbm", "pnm", "webp", "raw", "arw",
-		"svg", "stl", "eps", "dvi", "ps", "cbr", "jpf",
-		"cbz", "xpm", "ico", "cr2", "orf", "nef",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(imageExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isVideo() bool {
-	videoExts := []string{
-		"avi", "flv", "m2v", "m4v", "mkv", "mov", "mp4", "mpeg",
-		"mpg", "ogm", "ogv", "vob", "wmv", "webm", "m2ts",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(videoExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isMusic() bool {
-	musicExts := []string{
-		"aac", "m4a", "mp3", "ogg", "wma", "mka", "opus",
-		"alac", "ape", "flac", "wav",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(musicExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isCrypto() bool {
-	cryptoExts := []string{
-		"asc", "enc", "gpg", "pgp", "sig", "signature", "pfx", "p12",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(cryptoExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isDocument() bool {
-	documentExts := []string{
-		"djvu", "doc", "docx", "dvi", "eml", "eps", "fotd",
-		"odp", "odt", "pdf", "ppt", "pptx", "rtf",
-		"xls", "xlsx",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(documentExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isCompressed() bool {
-	compressedExts := []string{
-		"zip", "tar", "Z", "z", "gz", "bz2", "a", "ar", "7z",
-		"iso", "dmg", "tc", "rar", "par", "tgz", "xz", "txz",
-		"lz", "tlz", "lzma", "deb", "rpm", "zst",
-	}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(compressedExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isTemp() bool {
-	name := row.fileInfo.Name()
-
-	// XXXX~ or #XXXX#
-	if name[len(name)-1:] == "~" || (name[:1] == "#" && name[len(name)-1:] == "#") {
-		return true
-	}
-
-	tempExts := []string{"tmp", "swp", "swo", "swn", "bak", "bk"}
-
-	ext := ext(name)
-
-	if contains(tempExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (row *Row) isCompiled() bool {
-	compiledExts := []string{"class", "elc", "hi", "o", "pyc", "zwc"}
-
-	ext := ext(row.fileInfo.Name())
-
-	if contains(compiledExts, ext) {
-		return true
-	}
-
-	return false
-}
-
-func (w *Walker) PrintRoot(root string) {
-	fmt.Println(root)
-}
-
-func (w *Walker) PrintRow(row Row) {
-	fmt.Println(row.Str())
-}
-
-func (w *Walker) PrintResult() {
-	fmt.Printf("\n%d directories, %d files\n", w.dirNum, w.fileNum)
-}
-
-func (w *Walker) Walk(dir string, level uint) error {
-	if level > w.level {
-		return nil
-	}
-
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-
-	for i, file := range files {
-		if !w.includeDot && file.Name()[:1] == "." && file.Name() != "." {
-			continue
-		}
-
-		if int(level)-len(w.isEndDir) == 1 {
-			w.isEndDir = append(w.isEndDir, false)
-		}
-
-		if int(level) < len(w.isEndDir) {
-			w.isEndDir = w.isEndDir[:level]
-		}
-
-		var onRightAngle bool
-		if i == len(files)-1 {
-			onRightAngle = true
-			w.isEndDir[level-1] = true
-		}
-
-		row := Row{
-			fileInfo:     file,
-			level:        level,
-			onRightAngle: onRightAngle,
-			isBlank:      w.isEndDir,
-			colored:      w.colored,
-			permission:   w.permission,
-			uid:          w.uid,
-			gid:          w.gid,
-			size:         w.size,
-			datetime:     w.datetime,
-		}
-
-		w.PrintRow(row)
-
-		if file.IsDir() {
-			path := filepath.Join(dir, file.Name())
-			err := w.Walk(path, level+1)
-			if err != nil {
-				return err
-			}
-
-			w.dirNum++
-		} else {
-			w.fileNum++
-		}
-
-	}
-
-	return nil
-}
-
-func Tree(root string, opts ...Option) error {
-	w := &Walker{
-		dirNum:     0,
-		fileNum:    0,
-		isEndDir:   []bool{},
-		colored:    true,
-		level:      math.MaxUint64,
-		permission: false,
-		uid:        false,
-		gid:        false,
-		size:       false,
-		includeDot: false,
-		datetime:   false,
-	}
-
-	for _, o := range opts {
-		o.apply(w)
-	}
-
-	w.PrintRoot(root)
-
-	err := w.Walk(root, 1)
-	if err != nil {
-		return err
-	}
-
-	w.PrintResult()
-
-	return nil
-}
+// exitPartial is the exit status when some directories could not be read.
+const exitPartial = 2
 
 func main() {
 	app := &cli.App{
-		Version: version,
-		Name:    name,
-		Usage:   "Golang tree command.",
+		Version:   version,
+		Name:      name,
+		Usage:     "Golang tree command.",
+		ArgsUsage: "[directory...]",
 		Flags: []cli.Flag{
 			&cli.UintFlag{
 				Name:    "level",
@@ -764,10 +39,20 @@ func main() {
 				Value:   math.MaxUint64,
 				Usage:   "Descend only level directories deep.",
 			},
+			&cli.StringFlag{
+				Name:  "color",
+				Value: "auto",
+				Usage: "Color the output: auto, always or never. auto honors NO_COLOR and CLICOLOR_FORCE.",
+			},
 			&cli.BoolFlag{
-				Name:    "disable-color",
-				Aliases: []string{"d"},
-				Usage:   "Disable color.",
+				Name:   "disable-color",
+				Usage:  "Same as --color=never. Deprecated, -d now lists directories only.",
+				Hidden: true,
+			},
+			&cli.StringFlag{
+				Name:    "theme",
+				EnvVars: []string{"GOTREE_THEME"},
+				Usage:   "Override colors with the theme `FILE`.",
 			},
 			&cli.BoolFlag{
 				Name:    "permission",
@@ -789,30 +74,332 @@ func main() {
 				Aliases: []string{"s"},
 				Usage:   "Print the size.",
 			},
+			&cli.BoolFlag{
+				Name:  "si",
+				Usage: "Print sizes in powers of 1000 with SI prefixes (default).",
+			},
+			&cli.BoolFlag{
+				Name:  "iec",
+				Usage: "Print sizes in powers of 1024 with IEC units such as KiB.",
+			},
+			&cli.BoolFlag{
+				Name:  "bytes",
+				Usage: "Print sizes in exact bytes.",
+			},
+			&cli.BoolFlag{
+				Name:  "du",
+				Usage: "Print the accumulated size and allocated disk space of each directory.",
+			},
 			&cli.BoolFlag{
 				Name:    "datetime",
 				Aliases: []string{"D"},
 				Usage:   "Print file datetime.",
 			},
+			&cli.BoolFlag{
+				Name:  "git",
+				Usage: "Print the git status of each file.",
+			},
+			&cli.BoolFlag{
+				Name:    "full-path",
+				Aliases: []string{"f"},
+				Usage:   "Print the full path of each file.",
+			},
+			&cli.BoolFlag{
+				Name:  "abs",
+				Usage: "Print the absolute path of each file.",
+			},
+			&cli.BoolFlag{
+				Name:    "noindent",
+				Aliases: []string{"i"},
+				Usage:   "Do not print indentation lines.",
+			},
+			&cli.BoolFlag{
+				Name:  "noreport",
+				Usage: "Do not print the file and directory report at the end of the tree.",
+			},
+			&cli.BoolFlag{
+				Name:    "follow",
+				Aliases: []string{"l"},
+				Usage:   "Follow symbolic links to directories.",
+			},
 			&cli.BoolFlag{
 				Name:    "all",
 				Aliases: []string{"a"},
 				Usage:   "All files are listed.",
 			},
+			&cli.StringSliceFlag{
+				Name:    "pattern",
+				Aliases: []string{"P"},
+				Usage:   "List only those files that match the pattern. Globs may be separated by '|'.",
+			},
+			&cli.StringSliceFlag{
+				Name:    "ignore",
+				Aliases: []string{"I"},
+				Usage:   "Do not list files or directories that match the pattern.",
+			},
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "Omit directories which are empty after filtering.",
+			},
+			&cli.BoolFlag{
+				Name:    "dirs-only",
+				Aliases: []string{"d"},
+				Usage:   "List directories only.",
+			},
+			&cli.IntFlag{
+				Name:  "filelimit",
+				Usage: "Do not descend into directories with more than `N` entries.",
+			},
+			&cli.BoolFlag{
+				Name:  "gitignore",
+				Usage: "Do not list files ignored by git.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "ignore-file",
+				Usage: "Also read ignore files with this name, e.g. .dockerignore.",
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Value: "name",
+				Usage: "Sort by name, version, size, mtime, ctime, ext or none.",
+			},
+			&cli.BoolFlag{
+				Name:    "reverse",
+				Aliases: []string{"r"},
+				Usage:   "Reverse the sort order.",
+			},
+			&cli.BoolFlag{
+				Name:  "dirsfirst",
+				Usage: "List directories before files.",
+			},
+			&cli.BoolFlag{
+				Name:  "filesfirst",
+				Usage: "List files before directories.",
+			},
+			&cli.IntFlag{
+				Name:  "parallel",
+				Usage: "Read up to `N` directories concurrently ahead of the walk. Helps on network file systems or with a cold cache.",
+			},
+			&cli.BoolFlag{
+				Name:  "fromfile",
+				Usage: "Render the paths listed one per line or NUL-separated in the files given as arguments, or in standard input for - or no argument, instead of reading the file system.",
+			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"x"},
+				Usage:   "Browse the tree interactively and print the selected path on exit.",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Render again whenever files change, highlighting the changed ones. Linux only.",
+			},
+			&cli.StringFlag{
+				Name:    "html",
+				Aliases: []string{"H"},
+				Usage:   "Print an HTML page linking each file to `baseURL` joined with its path.",
+			},
+			&cli.BoolFlag{
+				Name:  "nolinks",
+				Usage: "Leave out the links of the HTML page.",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"J"},
+				Usage:   "Print the tree as a JSON document. Same as --format=json.",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "Print the tree as `format`: text, json, xml, dot, mermaid, markdown, markdown-list, ndjson, csv or html.",
+			},
+			&cli.StringFlag{
+				Name:  "links",
+				Usage: "Link each entry of the Markdown list to `baseURL` joined with its path.",
+			},
+			&cli.StringFlag{
+				Name:  "update-file",
+				Usage: "Replace the lines between the gotree:start and gotree:end markers of `file` with the tree, as a Markdown code block unless --format is given.",
+			},
+			&cli.BoolFlag{
+				Name:    "xml",
+				Aliases: []string{"X"},
+				Usage:   "Print the tree as an XML document in the format of GNU tree -X. Same as --format=xml.",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			root := c.Args().Get(0) // TODO: 引数の数をチェックする。
-
-			level := levelOption(c.Uint("level"))
-			colored := coloredOption(!c.Bool("disable-color") && isTerminal())
-			permission := permissionOption(c.Bool("permission"))
-			uid := uidOption(c.Bool("uid"))
-			gid := gidOption(c.Bool("gid"))
-			size := sizeOption(c.Bool("size"))
-			includeDot := includeDotOption(c.Bool("all"))
-			datetime := datetimeOption(c.Bool("datetime"))
-
-			err := Tree(root, colored, level, permission, uid, gid, size, includeDot, datetime)
+			fromFile := c.Bool("fromfile")
+			roots := c.Args().Slice()
+			if len(roots) == 0 {
+				roots = []string{"."}
+				if fromFile {
+					roots = []string{"-"}
+				}
+			}
+
+			// Missing roots are still listed with an error marker, and reported on stderr.
+			for _, root := range roots {
+				if fromFile {
+					break
+				}
+				if _, err := os.Stat(root); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", c.App.Name, err)
+				}
+			}
+
+			level := tree.WithLevel(c.Uint("level"))
+			mode := c.String("color")
+			if c.Bool("disable-color") {
+				fmt.Fprintf(os.Stderr, "%s: --disable-color is deprecated, use --color=never (-d now lists directories only)\n", c.App.Name)
+				mode = "never"
+			}
+			// The interactive view draws on the terminal even when stdout is captured.
+			interactive := c.Bool("interactive")
+			useColor, err := colorEnabled(mode, interactive || isTerminal())
+			if err != nil {
+				return err
+			}
+			colored := tree.WithColor(useColor)
+
+			colors := tree.NewTheme()
+			colors.ParseLSColors(os.Getenv("LS_COLORS"))
+			if file := c.String("theme"); file != "" {
+				if err := colors.ReadFile(file); err != nil {
+					return err
+				}
+			}
+			theme := tree.WithTheme(colors)
+
+			permission := tree.WithPermission(c.Bool("permission"))
+			uid := tree.WithUID(c.Bool("uid"))
+			gid := tree.WithGID(c.Bool("gid"))
+			size := tree.WithSize(c.Bool("size"))
+			du := tree.WithDU(c.Bool("du"))
+
+			format := tree.SizeSI
+			if c.Bool("iec") {
+				format = tree.SizeIEC
+			}
+			if c.Bool("bytes") {
+				format = tree.SizeBytes
+			}
+			sizeFormat := tree.WithSizeFormat(format)
+			includeDot := tree.WithIncludeDot(c.Bool("all"))
+			follow := tree.WithFollow(c.Bool("follow"))
+			datetime := tree.WithDatetime(c.Bool("datetime"))
+			git := tree.WithGit(c.Bool("git"))
+			fullPath := tree.WithFullPath(c.Bool("full-path"))
+			absPath := tree.WithAbsPath(c.Bool("abs"))
+			noIndent := tree.WithNoIndent(c.Bool("noindent"))
+			noReport := tree.WithNoReport(c.Bool("noreport"))
+
+			// -J, -X and -H are shorthands for --format.
+			formats := map[string]tree.OutputFormat{}
+			if c.IsSet("format") {
+				f, err := tree.ParseOutputFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				formats["--format"] = f
+			}
+			if c.Bool("json") {
+				formats["-J"] = tree.OutputJSON
+			}
+			if c.Bool("xml") {
+				formats["-X"] = tree.OutputXML
+			}
+			if c.IsSet("html") {
+				formats["-H"] = tree.OutputHTML
+			}
+			if c.IsSet("update-file") && len(formats) == 0 {
+				formats["--update-file"] = tree.OutputMarkdown
+			}
+
+			outputFormat, err := selectFormat(formats)
+			if err != nil {
+				return err
+			}
+			output := tree.WithFormat(outputFormat)
+
+			htmlBase := c.String("html")
+			if c.Bool("nolinks") {
+				htmlBase = ""
+			}
+			htmlLinks := tree.WithHTMLLinks(htmlBase)
+			links := tree.WithMarkdownLinks(c.String("links"))
+			pattern := tree.WithPattern(c.StringSlice("pattern")...)
+			ignore := tree.WithIgnorePattern(c.StringSlice("ignore")...)
+			prune := tree.WithPrune(c.Bool("prune"))
+			dirsOnly := tree.WithDirsOnly(c.Bool("dirs-only"))
+			fileLimit := tree.WithFileLimit(c.Int("filelimit"))
+			gitignore := tree.WithGitignore(c.Bool("gitignore"))
+			ignoreFile := tree.WithIgnoreFile(c.StringSlice("ignore-file")...)
+			reverse := tree.WithReverse(c.Bool("reverse"))
+			dirsFirst := tree.WithDirsFirst(c.Bool("dirsfirst"))
+			filesFirst := tree.WithFilesFirst(c.Bool("filesfirst"))
+
+			sortOrder, err := tree.ParseSortOrder(c.String("sort"))
+			if err != nil {
+				return err
+			}
+			sort := tree.WithSort(sortOrder)
+			parallel := tree.WithParallel(c.Int("parallel"))
+
+			opts := []tree.Option{colored, theme, level, permission, uid, gid, size, du, sizeFormat, includeDot, follow, datetime, git, fullPath, absPath, noIndent, noReport, output, links, htmlLinks, pattern, ignore, prune, dirsOnly, fileLimit, gitignore, ignoreFile, sort, reverse, dirsFirst, filesFirst, parallel}
+
+			if fromFile {
+				if c.Bool("watch") {
+					return errors.New("--watch cannot be used with --fromfile")
+				}
+
+				// Each list is rendered as a root named after its file, or "." for standard input.
+				for i, file := range roots {
+					list, err := readPathList(file)
+					if err != nil {
+						return err
+					}
+					if file == "-" {
+						roots[i] = "."
+					}
+					opts = append(opts, tree.WithPathList(roots[i], list))
+				}
+			}
+
+			if interactive {
+				selected, err := tui.Browse(roots, opts...)
+				if err != nil {
+					return err
+				}
+				if selected == "" {
+					return cli.Exit("", 1)
+				}
+
+				fmt.Println(selected)
+				return nil
+			}
+
+			if c.Bool("watch") {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				sig := make(chan os.Signal, 1)
+				signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+				go func() {
+					<-sig
+					cancel()
+				}()
+
+				return tree.Watch(ctx, roots, opts...)
+			}
+
+			if c.IsSet("update-file") {
+				err = tree.UpdateFile(c.String("update-file"), roots, opts...)
+			} else {
+				err = tree.Trees(roots, opts...)
+			}
+			if errors.Is(err, tree.ErrPartial) {
+				// The unreadable directories are already reported in the output.
+				return cli.Exit("", exitPartial)
+			}
 			if err != nil {
 				return err
 			}
@@ -828,6 +415,66 @@ func main() {
 
 }
 
+// colorEnabled decides whether to color the output for a --color mode.
+// In auto mode a non-empty NO_COLOR disables color, and CLICOLOR_FORCE other
+// than "0" forces it even when the output is not a terminal.
+func colorEnabled(mode string, terminal bool) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+	default:
+		return false, fmt.Errorf("invalid color mode %q: want auto, always or never", mode)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false, nil
+	}
+
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true, nil
+	}
+
+	return terminal, nil
+}
+
+// selectFormat returns the output format chosen by the flags set, given by
+// name. At most one of them may be set, and the text tree is the default.
+func selectFormat(formats map[string]tree.OutputFormat) (tree.OutputFormat, error) {
+	if len(formats) > 1 {
+		names := make([]string, 0, len(formats))
+		for name := range formats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return tree.OutputText, fmt.Errorf("only one of %s can be used", strings.Join(names, ", "))
+	}
+
+	for _, f := range formats {
+		return f, nil
+	}
+
+	return tree.OutputText, nil
+}
+
+// readPathList reads the path list in file, or in standard input for "-".
+func readPathList(file string) (*tree.PathList, error) {
+	if file == "-" {
+		return tree.ReadPathList(os.Stdin)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return tree.ReadPathList(f)
+}
+
 func isTerminal() bool {
 	if isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()) {
 		return true
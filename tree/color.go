@@ -0,0 +1,90 @@
+package tree
+
+import "fmt"
+
+const (
+	// connector
+	crossConnector      = "├── "
+	rightAngleConnector = "└── "
+	lineConnector       = "│   "
+	blankConnector      = "    "
+
+	// print color
+	redFormat          = "\x1b[31m%s\x1b[0m"
+	greenFormat        = "\x1b[32m%s\x1b[0m"
+	yellowFormat       = "\x1b[33m%s\x1b[0m"
+	blueFormat         = "\x1b[34m%s\x1b[0m"
+	purpleFormat       = "\x1b[35m%s\x1b[0m"
+	cyanFormat         = "\x1b[36m%s\x1b[0m"
+	darkGrayFormat     = "\x1b[90m%s\x1b[0m"
+	lightRedFormat     = "\x1b[91m%s\x1b[0m"
+	lightGreenFormat   = "\x1b[92m%s\x1b[0m"
+	lightYellowFormat  = "\x1b[93m%s\x1b[0m"
+	lightBlueFormat    = "\x1b[94m%s\x1b[0m"
+	lightMagentaFormat = "\x1b[95m%s\x1b[0m"
+	lightCyanFormat    = "\x1b[96m%s\x1b[0m"
+
+	// format
+	underLineFormat = "\x1b[4m%s\x1b[0m"
+	reverseFormat   = "\x1b[7m%s\x1b[0m"
+)
+
+func colorRed(s string) string {
+	return fmt.Sprintf(redFormat, s)
+}
+
+func colorGreen(s string) string {
+	return fmt.Sprintf(greenFormat, s)
+}
+
+func colorYellow(s string) string {
+	return fmt.Sprintf(yellowFormat, s)
+}
+
+func colorBlue(s string) string {
+	return fmt.Sprintf(blueFormat, s)
+}
+
+func colorPurple(s string) string {
+	return fmt.Sprintf(purpleFormat, s)
+}
+
+func colorCyan(s string) string {
+	return fmt.Sprintf(cyanFormat, s)
+}
+
+func colorDarkGray(s string) string {
+	return fmt.Sprintf(darkGrayFormat, s)
+}
+
+func colorLightRed(s string) string {
+	return fmt.Sprintf(lightRedFormat, s)
+}
+
+func colorLightGreen(s string) string {
+	return fmt.Sprintf(lightGreenFormat, s)
+}
+
+func colorLightYellow(s string) string {
+	return fmt.Sprintf(lightYellowFormat, s)
+}
+
+func colorLightBlue(s string) string {
+	return fmt.Sprintf(lightBlueFormat, s)
+}
+
+func colorLightMagenta(s string) string {
+	return fmt.Sprintf(lightMagentaFormat, s)
+}
+
+func colorLightCyan(s string) string {
+	return fmt.Sprintf(lightCyanFormat, s)
+}
+
+func formatUnderLine(s string) string {
+	return fmt.Sprintf(underLineFormat, s)
+}
+
+func formatReverse(s string) string {
+	return fmt.Sprintf(reverseFormat, s)
+}
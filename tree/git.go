@@ -0,0 +1,128 @@
+package tree
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findGitDir looks for the git repository containing dir. It returns the top
+// of the working tree and the git directory, or empty strings outside a repository.
+func findGitDir(dir string) (string, string) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+
+		fi, err := os.Stat(dotGit)
+		if err == nil {
+			if fi.IsDir() {
+				return dir, dotGit
+			}
+
+			// Worktrees and submodules point to the git directory with a "gitdir:" line.
+			if b, err := ioutil.ReadFile(dotGit); err == nil {
+				line := strings.TrimSpace(string(b))
+				if strings.HasPrefix(line, "gitdir:") {
+					gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+					if !filepath.IsAbs(gitDir) {
+						gitDir = filepath.Join(dir, gitDir)
+					}
+
+					return dir, gitDir
+				}
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitConfigValue returns the value of key in section of a git config file.
+// Section and key are matched case-insensitively.
+func gitConfigValue(path, section, key string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	var current string
+	var value string
+	var found bool
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+
+			current = strings.ToLower(strings.TrimSpace(line[1:end]))
+			continue
+		}
+
+		if current != strings.ToLower(section) {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), key) {
+			continue
+		}
+
+		// The last assignment wins, as in git.
+		value = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		found = true
+	}
+
+	return value, found
+}
+
+// globalExcludesFile returns the path of the excludes file configured by
+// core.excludesFile, or git's default location.
+func globalExcludesFile(gitDir string) string {
+	home, _ := os.UserHomeDir()
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+
+	configs := []string{}
+	if gitDir != "" {
+		configs = append(configs, filepath.Join(gitDir, "config"))
+	}
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+	if xdg != "" {
+		configs = append(configs, filepath.Join(xdg, "git", "config"))
+	}
+
+	for _, c := range configs {
+		if v, ok := gitConfigValue(c, "core", "excludesFile"); ok {
+			if strings.HasPrefix(v, "~/") && home != "" {
+				v = filepath.Join(home, v[2:])
+			}
+
+			return v
+		}
+	}
+
+	if xdg == "" {
+		return ""
+	}
+
+	return filepath.Join(xdg, "git", "ignore")
+}
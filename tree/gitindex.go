@@ -0,0 +1,196 @@
+package tree
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitIndexEntry is a file staged in the index.
+type gitIndexEntry struct {
+	mtimeSec  uint32
+	mtimeNsec uint32
+	mode      uint32
+	size      uint32
+	hash      gitHash
+	stage     int
+}
+
+// readGitIndex reads the entries of an index file of version 2, 3 or 4,
+// keyed by their slash separated path. Of a conflicted path only the
+// highest stage is kept.
+func readGitIndex(path string) (map[string]gitIndexEntry, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]gitIndexEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < 12 || string(b[:4]) != "DIRC" {
+		return nil, fmt.Errorf("%s: not an index file", path)
+	}
+
+	version := binary.BigEndian.Uint32(b[4:])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("%s: unsupported index version %d", path, version)
+	}
+
+	errTruncated := fmt.Errorf("%s: truncated index", path)
+
+	n := int(binary.BigEndian.Uint32(b[8:]))
+	entries := make(map[string]gitIndexEntry, n)
+	pos := 12
+	var prev string
+
+	for i := 0; i < n; i++ {
+		start := pos
+		if pos+62 > len(b) {
+			return nil, errTruncated
+		}
+
+		e := gitIndexEntry{
+			mtimeSec:  binary.BigEndian.Uint32(b[pos+8:]),
+			mtimeNsec: binary.BigEndian.Uint32(b[pos+12:]),
+			mode:      binary.BigEndian.Uint32(b[pos+24:]),
+			size:      binary.BigEndian.Uint32(b[pos+36:]),
+		}
+		copy(e.hash[:], b[pos+40:pos+60])
+
+		flags := binary.BigEndian.Uint16(b[pos+60:])
+		e.stage = int(flags>>12) & 3
+		pos += 62
+
+		// Extended flags only exist from version 3 on.
+		if version >= 3 && flags&0x4000 != 0 {
+			pos += 2
+		}
+
+		var name string
+		if version == 4 {
+			strip, m := gitOffsetVarint(b[pos:])
+			pos += m
+			if int(strip) > len(prev) {
+				return nil, errTruncated
+			}
+
+			nul := bytes.IndexByte(b[pos:], 0)
+			if nul < 0 {
+				return nil, errTruncated
+			}
+
+			name = prev[:len(prev)-int(strip)] + string(b[pos:pos+nul])
+			pos += nul + 1
+		} else {
+			nul := bytes.IndexByte(b[pos:], 0)
+			if nul < 0 {
+				return nil, errTruncated
+			}
+
+			name = string(b[pos : pos+nul])
+			// Entries are padded with 1 to 8 NUL bytes to a multiple of 8.
+			pos = start + (pos-start+nul+8)&^7
+		}
+		prev = name
+
+		if old, ok := entries[name]; !ok || e.stage > old.stage {
+			entries[name] = e
+		}
+	}
+
+	return entries, nil
+}
+
+// gitCommonDir returns the directory holding the objects and refs, which
+// differs from gitDir in a linked worktree.
+func gitCommonDir(gitDir string) string {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+
+	dir := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+
+	return dir
+}
+
+var errGitUnborn = errors.New("HEAD does not point to a commit yet")
+
+// resolveGitHead returns the commit HEAD points to.
+func resolveGitHead(gitDir, commonDir string) (gitHash, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return gitHash{}, err
+	}
+
+	ref := strings.TrimSpace(string(b))
+	for i := 0; strings.HasPrefix(ref, "ref: "); i++ {
+		if i > 5 {
+			return gitHash{}, errors.New("too many symbolic refs")
+		}
+
+		ref, err = readGitRef(commonDir, strings.TrimSpace(strings.TrimPrefix(ref, "ref: ")))
+		if err != nil {
+			return gitHash{}, err
+		}
+	}
+
+	return parseGitHash(ref)
+}
+
+// readGitRef returns the content of a loose ref, falling back to packed-refs.
+func readGitRef(commonDir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(commonDir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	packed, err := ioutil.ReadFile(filepath.Join(commonDir, "packed-refs"))
+	if err == nil {
+		for _, line := range strings.Split(string(packed), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[1] == name {
+				return fields[0], nil
+			}
+		}
+	}
+
+	return "", errGitUnborn
+}
+
+// hashGitBlob returns the object name of the file at path as git would store
+// it: the content of a regular file or the target of a symbolic link.
+func hashGitBlob(path string, fi os.FileInfo) (gitHash, error) {
+	var content []byte
+	var err error
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		var target string
+		target, err = os.Readlink(path)
+		content = []byte(target)
+	} else {
+		content, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return gitHash{}, err
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+
+	var sum gitHash
+	copy(sum[:], h.Sum(nil))
+
+	return sum, nil
+}
@@ -0,0 +1,598 @@
+package tree
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type gitHash [20]byte
+
+func (h gitHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func parseGitHash(s string) (gitHash, error) {
+	var h gitHash
+
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	copy(h[:], b)
+
+	return h, nil
+}
+
+// Object types as numbered in packfiles.
+const (
+	gitObjCommit   = 1
+	gitObjTree     = 2
+	gitObjBlob     = 3
+	gitObjTag      = 4
+	gitObjOfsDelta = 6
+	gitObjRefDelta = 7
+)
+
+var gitObjTypes = map[string]int{
+	"commit": gitObjCommit,
+	"tree":   gitObjTree,
+	"blob":   gitObjBlob,
+	"tag":    gitObjTag,
+}
+
+var errGitObjectNotFound = errors.New("git object not found")
+
+// gitObjects reads objects from the loose object directory and the packfiles
+// of a repository. It must be closed to release the packfiles.
+type gitObjects struct {
+	dir   string
+	packs []*gitPack
+	cache gitObjectCache
+}
+
+func openGitObjects(commonDir string) (*gitObjects, error) {
+	dir := filepath.Join(commonDir, "objects")
+
+	idxs, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	o := &gitObjects{dir: dir}
+	for _, idx := range idxs {
+		p, err := openGitPack(idx)
+		if err != nil {
+			return nil, err
+		}
+		o.packs = append(o.packs, p)
+	}
+
+	return o, nil
+}
+
+func (o *gitObjects) close() {
+	for _, p := range o.packs {
+		p.close()
+	}
+}
+
+// read returns the type and the content of the object h.
+func (o *gitObjects) read(h gitHash) (int, []byte, error) {
+	s := h.String()
+
+	b, err := ioutil.ReadFile(filepath.Join(o.dir, s[:2], s[2:]))
+	if err == nil {
+		return parseLooseObject(b)
+	}
+	if !os.IsNotExist(err) {
+		return 0, nil, err
+	}
+
+	for _, p := range o.packs {
+		if off, ok := p.find(h); ok {
+			return o.readPacked(p, off)
+		}
+	}
+
+	return 0, nil, fmt.Errorf("%w: %s", errGitObjectNotFound, s)
+}
+
+func parseLooseObject(b []byte) (int, []byte, error) {
+	data, err := inflate(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	nul := bytes.IndexByte(data, 0)
+	sp := bytes.IndexByte(data, ' ')
+	if nul < 0 || sp < 0 || sp > nul {
+		return 0, nil, errors.New("malformed loose object")
+	}
+
+	typ, ok := gitObjTypes[string(data[:sp])]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown object type %q", data[:sp])
+	}
+
+	return typ, data[nul+1:], nil
+}
+
+func inflate(b []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// gitObjectCacheSize bounds the bytes of the objects kept by gitObjectCache.
+const gitObjectCacheSize = 32 << 20
+
+type gitObjectKey struct {
+	pack *gitPack
+	off  int64
+}
+
+type gitCachedObject struct {
+	typ  int
+	data []byte
+}
+
+// gitObjectCache keeps the latest objects read from packfiles, so that the
+// bases shared by delta chains are not inflated again for every delta. The
+// oldest objects are dropped first once the cache is full.
+type gitObjectCache struct {
+	objects map[gitObjectKey]gitCachedObject
+	order   []gitObjectKey
+	size    int
+}
+
+func (c *gitObjectCache) get(k gitObjectKey) (gitCachedObject, bool) {
+	obj, ok := c.objects[k]
+	return obj, ok
+}
+
+func (c *gitObjectCache) add(k gitObjectKey, obj gitCachedObject) {
+	if len(obj.data) > gitObjectCacheSize/4 {
+		return
+	}
+
+	if c.objects == nil {
+		c.objects = map[gitObjectKey]gitCachedObject{}
+	}
+	if _, ok := c.objects[k]; ok {
+		return
+	}
+
+	for c.size+len(obj.data) > gitObjectCacheSize && len(c.order) > 0 {
+		c.size -= len(c.objects[c.order[0]].data)
+		delete(c.objects, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	c.objects[k] = obj
+	c.order = append(c.order, k)
+	c.size += len(obj.data)
+}
+
+// readPacked returns the type and content of the object at off in p, from
+// the cache if it was read recently.
+func (o *gitObjects) readPacked(p *gitPack, off int64) (int, []byte, error) {
+	k := gitObjectKey{p, off}
+	if obj, ok := o.cache.get(k); ok {
+		return obj.typ, obj.data, nil
+	}
+
+	typ, data, err := p.read(off, o)
+	if err != nil {
+		return 0, nil, err
+	}
+	o.cache.add(k, gitCachedObject{typ, data})
+
+	return typ, data, nil
+}
+
+// gitPack is a packfile with its version 2 index. The packfile itself is
+// opened on the first read and objects are read from it at their offsets,
+// rather than loading it whole.
+type gitPack struct {
+	path    string
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []byte
+	large   []byte
+	file    *os.File
+	size    int64
+}
+
+func openGitPack(idxPath string) (*gitPack, error) {
+	idx, err := ioutil.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte("\xfftOc")) || binary.BigEndian.Uint32(idx[4:]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index", idxPath)
+	}
+
+	p := &gitPack{path: strings.TrimSuffix(idxPath, ".idx") + ".pack"}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[8+i*4:])
+	}
+
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(idx) < pos+n*(20+4+4) {
+		return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+	}
+
+	p.hashes = idx[pos : pos+n*20]
+	pos += n * 20
+	pos += n * 4 // CRC32s
+	p.offsets = idx[pos : pos+n*4]
+	pos += n * 4
+	p.large = idx[pos:]
+
+	return p, nil
+}
+
+// find returns the offset of h in the packfile.
+func (p *gitPack) find(h gitHash) (int64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(p.fanout[h[0]-1])
+	}
+	hi := int(p.fanout[h[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*20:(lo+i+1)*20], h[:]) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.hashes[i*20:(i+1)*20], h[:]) {
+		return 0, false
+	}
+
+	off := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off), true
+	}
+
+	j := int(off&0x7fffffff) * 8
+	if j+8 > len(p.large) {
+		return 0, false
+	}
+
+	return int64(binary.BigEndian.Uint64(p.large[j:])), true
+}
+
+func (p *gitPack) open() error {
+	if p.file != nil {
+		return nil
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	p.file, p.size = f, fi.Size()
+	return nil
+}
+
+func (p *gitPack) close() {
+	if p.file != nil {
+		p.file.Close()
+		p.file = nil
+	}
+}
+
+// gitPackHeaderSize is enough for the longest object header of a packfile:
+// the type and size, then a base offset or a base object name.
+const gitPackHeaderSize = 10 + 20
+
+// read returns the type and content of the object at off, resolving deltas.
+func (p *gitPack) read(off int64, objects *gitObjects) (int, []byte, error) {
+	if err := p.open(); err != nil {
+		return 0, nil, err
+	}
+
+	if off < 0 || off >= p.size {
+		return 0, nil, fmt.Errorf("%s: offset %d out of range", p.path, off)
+	}
+
+	b := make([]byte, gitPackHeaderSize)
+	n, err := p.file.ReadAt(b, off)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	b = b[:n]
+
+	c := b[0]
+	typ := int(c>>4) & 7
+	size := int(c & 0x0f)
+	shift := uint(4)
+	pos := 1
+	for c&0x80 != 0 {
+		if pos >= len(b) {
+			return 0, nil, fmt.Errorf("%s: truncated object header", p.path)
+		}
+		c = b[pos]
+		size |= int(c&0x7f) << shift
+		shift += 7
+		pos++
+	}
+
+	var baseTyp int
+	var base []byte
+
+	switch typ {
+	case gitObjCommit, gitObjTree, gitObjBlob, gitObjTag:
+		data, err := p.inflateAt(off+int64(pos), size)
+		return typ, data, err
+	case gitObjOfsDelta:
+		rel, n := gitOffsetVarint(b[pos:])
+		pos += n
+		baseTyp, base, err = objects.readPacked(p, off-rel)
+	case gitObjRefDelta:
+		if pos+20 > len(b) {
+			return 0, nil, fmt.Errorf("%s: truncated delta", p.path)
+		}
+		var h gitHash
+		copy(h[:], b[pos:])
+		pos += 20
+		baseTyp, base, err = objects.read(h)
+	default:
+		return 0, nil, fmt.Errorf("%s: unknown object type %d", p.path, typ)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	delta, err := p.inflateAt(off+int64(pos), size)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data, err := applyGitDelta(base, delta)
+	return baseTyp, data, err
+}
+
+// inflateAt decompresses the size bytes of data stored at off.
+func (p *gitPack) inflateAt(off int64, size int) ([]byte, error) {
+	r, err := zlib.NewReader(io.NewSectionReader(p.file, off, p.size-off))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("%s: %v", p.path, err)
+	}
+
+	return data, nil
+}
+
+// gitOffsetVarint decodes the base offset of an OFS_DELTA object and
+// returns it with the number of bytes read.
+func gitOffsetVarint(b []byte) (int64, int) {
+	var v int64
+	for i, c := range b {
+		if i > 0 {
+			v++
+		}
+		v = v<<7 | int64(c&0x7f)
+		if c&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+
+	return v, len(b)
+}
+
+// gitSizeVarint decodes a little-endian base 128 size of a delta header.
+func gitSizeVarint(b []byte) (int, int) {
+	var v, shift int
+	for i, c := range b {
+		v |= int(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+
+	return v, len(b)
+}
+
+func applyGitDelta(base, delta []byte) ([]byte, error) {
+	errDelta := errors.New("malformed delta")
+
+	srcSize, n := gitSizeVarint(delta)
+	delta = delta[n:]
+	dstSize, n := gitSizeVarint(delta)
+	delta = delta[n:]
+
+	if srcSize != len(base) {
+		return nil, errDelta
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			if op == 0 || int(op) > len(delta) {
+				return nil, errDelta
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		var off, size int
+		for i := uint(0); i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errDelta
+				}
+				off |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		for i := uint(0); i < 3; i++ {
+			if op&(0x10<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errDelta
+				}
+				size |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+
+		if off+size > len(base) {
+			return nil, errDelta
+		}
+		out = append(out, base[off:off+size]...)
+	}
+
+	if len(out) != dstSize {
+		return nil, errDelta
+	}
+
+	return out, nil
+}
+
+// gitTreeEntry is an entry of a tree object.
+type gitTreeEntry struct {
+	mode uint32
+	name string
+	hash gitHash
+}
+
+func parseGitTree(b []byte) ([]gitTreeEntry, error) {
+	var entries []gitTreeEntry
+
+	for len(b) > 0 {
+		sp := bytes.IndexByte(b, ' ')
+		nul := bytes.IndexByte(b, 0)
+		if sp < 0 || nul < sp || nul+21 > len(b) {
+			return nil, errors.New("malformed tree object")
+		}
+
+		mode, err := strconv.ParseUint(string(b[:sp]), 8, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		e := gitTreeEntry{mode: uint32(mode), name: string(b[sp+1 : nul])}
+		copy(e.hash[:], b[nul+1:nul+21])
+		entries = append(entries, e)
+
+		b = b[nul+21:]
+	}
+
+	return entries, nil
+}
+
+// readTree returns the entries of the tree object h.
+func (o *gitObjects) readTree(h gitHash) ([]gitTreeEntry, error) {
+	typ, data, err := o.read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != gitObjTree {
+		return nil, fmt.Errorf("%s is not a tree", h)
+	}
+
+	return parseGitTree(data)
+}
+
+// subtree returns the tree at the slash separated path dir below the tree h,
+// reading only the trees on the way. It returns false if there is no such
+// tree.
+func (o *gitObjects) subtree(h gitHash, dir string) (gitHash, bool, error) {
+	if dir == "" || dir == "." {
+		return h, true, nil
+	}
+
+	for _, name := range strings.Split(dir, "/") {
+		entries, err := o.readTree(h)
+		if err != nil {
+			return gitHash{}, false, err
+		}
+
+		found := false
+		for _, e := range entries {
+			if e.name == name && e.mode == 0040000 {
+				h, found = e.hash, true
+				break
+			}
+		}
+		if !found {
+			return gitHash{}, false, nil
+		}
+	}
+
+	return h, true, nil
+}
+
+// readTreeFiles adds every file below the tree h to files, keyed by its
+// slash separated path prefixed with prefix.
+func (o *gitObjects) readTreeFiles(h gitHash, prefix string, files map[string]gitTreeEntry) error {
+	entries, err := o.readTree(h)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.mode == 0040000 {
+			if err := o.readTreeFiles(e.hash, prefix+e.name+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		files[prefix+e.name] = e
+	}
+
+	return nil
+}
+
+// commitTree returns the tree of the commit h.
+func (o *gitObjects) commitTree(h gitHash) (gitHash, error) {
+	typ, data, err := o.read(h)
+	if err != nil {
+		return gitHash{}, err
+	}
+	if typ != gitObjCommit {
+		return gitHash{}, fmt.Errorf("%s is not a commit", h)
+	}
+
+	if !bytes.HasPrefix(data, []byte("tree ")) || len(data) < 45 {
+		return gitHash{}, fmt.Errorf("%s: malformed commit", h)
+	}
+
+	return parseGitHash(string(data[5:45]))
+}
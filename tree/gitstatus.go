@@ -0,0 +1,363 @@
+package tree
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitState is the state of an entry on one side of a git status.
+type GitState byte
+
+const (
+	GitUnmodified GitState = '-'
+	GitNew        GitState = 'N'
+	GitModified   GitState = 'M'
+	GitDeleted    GitState = 'D'
+	GitIgnored    GitState = 'I'
+	GitConflicted GitState = 'U'
+)
+
+// rank orders the states rolled up on directories.
+func (s GitState) rank() int {
+	switch s {
+	case GitConflicted:
+		return 4
+	case GitModified, GitDeleted:
+		return 3
+	case GitNew:
+		return 2
+	}
+
+	return 0
+}
+
+// GitStatus is the status of an entry in its repository: Staged compares
+// the index to HEAD and Unstaged the working tree to the index. The status of
+// a directory rolls up the most important state of everything below it.
+type GitStatus struct {
+	Staged   GitState
+	Unstaged GitState
+}
+
+// String returns the two letter code of the status, e.g. "-M".
+func (s GitStatus) String() string {
+	return string([]byte{byte(s.Staged), byte(s.Unstaged)})
+}
+
+var gitClean = GitStatus{GitUnmodified, GitUnmodified}
+
+// gitRepoStatus holds the status of every changed file below a directory of
+// a repository, keyed by slash separated paths relative to the top of the
+// working tree.
+type gitRepoStatus struct {
+	top         string
+	files       map[string]GitStatus
+	dirs        map[string]GitStatus
+	ignoredDirs map[string]bool
+}
+
+// loadGitStatus computes the status below dir, which must be absolute. It
+// returns nil if dir is not inside a repository or the repository cannot be
+// read, so that the caller can render without the status. Only the part of
+// HEAD and of the index below dir is looked at.
+func loadGitStatus(dir string) *gitRepoStatus {
+	top, gitDir := findGitDir(dir)
+	if top == "" {
+		return nil
+	}
+
+	rel, err := filepath.Rel(top, dir)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	prefix := ""
+	if rel != "." {
+		prefix = rel + "/"
+	}
+
+	commonDir := gitCommonDir(gitDir)
+
+	index, err := readGitIndex(filepath.Join(gitDir, "index"))
+	if err != nil {
+		return nil
+	}
+
+	objects, err := openGitObjects(commonDir)
+	if err != nil {
+		return nil
+	}
+	defer objects.close()
+
+	head := map[string]gitTreeEntry{}
+	commit, err := resolveGitHead(gitDir, commonDir)
+	if err == nil {
+		err = readGitHeadFiles(objects, commit, rel, prefix, head)
+	}
+	if err != nil && err != errGitUnborn {
+		return nil
+	}
+
+	var indexTime os.FileInfo
+	if fi, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+		indexTime = fi
+	}
+
+	s := &gitRepoStatus{
+		top:         top,
+		files:       map[string]GitStatus{},
+		dirs:        map[string]GitStatus{},
+		ignoredDirs: map[string]bool{},
+	}
+
+	for name, e := range index {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		st := gitClean
+
+		if e.stage > 0 {
+			st = GitStatus{GitConflicted, GitConflicted}
+		} else {
+			if h, ok := head[name]; !ok {
+				st.Staged = GitNew
+			} else if h.hash != e.hash || h.mode != e.mode {
+				st.Staged = GitModified
+			}
+
+			st.Unstaged = worktreeState(filepath.Join(top, filepath.FromSlash(name)), e, indexTime)
+		}
+
+		if st != gitClean {
+			s.set(name, st)
+		}
+	}
+
+	for name := range head {
+		if _, ok := index[name]; !ok {
+			s.set(name, GitStatus{GitDeleted, GitUnmodified})
+		}
+	}
+
+	tracked := map[string]bool{}
+	for name := range index {
+		for d := path.Dir(name); d != "." && !tracked[d]; d = path.Dir(d) {
+			tracked[d] = true
+		}
+	}
+
+	s.walkUntracked(dir, gitDir, index, tracked)
+
+	return s
+}
+
+// readGitHeadFiles adds the files of the commit below the slash separated
+// directory dir to files, keyed by their paths relative to the top of the
+// working tree, which all start with prefix.
+func readGitHeadFiles(objects *gitObjects, commit gitHash, dir, prefix string, files map[string]gitTreeEntry) error {
+	tree, err := objects.commitTree(commit)
+	if err != nil {
+		return err
+	}
+
+	sub, ok, err := objects.subtree(tree, dir)
+	if err != nil || !ok {
+		return err
+	}
+
+	return objects.readTreeFiles(sub, prefix, files)
+}
+
+// worktreeState compares the file at p to its index entry. The content is
+// only hashed when the size and modification time do not tell.
+func worktreeState(p string, e gitIndexEntry, index os.FileInfo) GitState {
+	// Submodules are not looked into.
+	if e.mode == 0160000 {
+		return GitUnmodified
+	}
+
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return GitDeleted
+	}
+
+	isLink := fi.Mode()&os.ModeSymlink != 0
+	if isLink != (e.mode == 0120000) || fi.IsDir() {
+		return GitModified
+	}
+
+	if !isLink && (fi.Mode()&0100 != 0) != (e.mode&0100 != 0) {
+		return GitModified
+	}
+
+	if uint32(fi.Size()) != e.size {
+		return GitModified
+	}
+
+	mtime := fi.ModTime()
+	sameTime := uint32(mtime.Unix()) == e.mtimeSec && uint32(mtime.Nanosecond()) == e.mtimeNsec
+	// A file written in the same second as the index may have changed
+	// without its modification time telling.
+	racy := index != nil && !mtime.Before(index.ModTime().Truncate(1e9))
+	if sameTime && !racy {
+		return GitUnmodified
+	}
+
+	h, err := hashGitBlob(p, fi)
+	if err != nil || h != e.hash {
+		return GitModified
+	}
+
+	return GitUnmodified
+}
+
+// walkUntracked marks the files below dir which are not in the index as new,
+// or as ignored when the ignore files of the repository exclude them.
+func (s *gitRepoStatus) walkUntracked(dir, gitDir string, index map[string]gitIndexEntry, tracked map[string]bool) {
+	var m *ignoreMatcher
+	if excludes := globalExcludesFile(gitDir); excludes != "" {
+		m = m.load(excludes, s.top)
+	}
+	m = m.load(filepath.Join(gitDir, "info", "exclude"), s.top)
+
+	// Load the ignore files of the directories above dir.
+	rel, err := filepath.Rel(s.top, dir)
+	if err != nil {
+		return
+	}
+
+	parent := s.top
+	ignored := false
+	m = m.loadDir(parent, []string{".gitignore"})
+	if rel != "." {
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			abs := filepath.Join(parent, name)
+			ignored = ignored || m.ignored(filepath.ToSlash(abs), true)
+			if ignored && !tracked[s.rel(abs)] {
+				s.ignoredDirs[s.rel(abs)] = true
+				return
+			}
+
+			parent = abs
+			m = m.loadDir(parent, []string{".gitignore"})
+		}
+	}
+
+	s.walkUntrackedDir(dir, m, ignored, index, tracked)
+}
+
+// walkUntrackedDir marks the untracked files below dir. Everything below an
+// ignored directory is ignored, even when no rule matches it.
+func (s *gitRepoStatus) walkUntrackedDir(dir string, m *ignoreMatcher, ignoredDir bool, index map[string]gitIndexEntry, tracked map[string]bool) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, fi := range files {
+		if fi.Name() == ".git" {
+			continue
+		}
+
+		abs := filepath.Join(dir, fi.Name())
+		rel := s.rel(abs)
+		if _, ok := index[rel]; ok {
+			continue
+		}
+
+		ignored := ignoredDir || m.ignored(filepath.ToSlash(abs), fi.IsDir())
+		staged := s.files[rel].Staged
+		if staged == 0 {
+			staged = GitUnmodified
+		}
+
+		if fi.IsDir() {
+			// A nested repository is a single untracked entry.
+			if _, err := os.Lstat(filepath.Join(abs, ".git")); err == nil {
+				if !ignored {
+					s.set(rel, GitStatus{staged, GitNew})
+				}
+				continue
+			}
+
+			if ignored && !tracked[rel] {
+				s.ignoredDirs[rel] = true
+				continue
+			}
+
+			s.walkUntrackedDir(abs, m.loadDir(abs, []string{".gitignore"}), ignored, index, tracked)
+			continue
+		}
+
+		if ignored {
+			s.files[rel] = GitStatus{staged, GitIgnored}
+		} else {
+			s.set(rel, GitStatus{staged, GitNew})
+		}
+	}
+}
+
+// set records the status of the file name and rolls it up on its parents.
+func (s *gitRepoStatus) set(name string, st GitStatus) {
+	s.files[name] = st
+
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		d, ok := s.dirs[dir]
+		if !ok {
+			d = gitClean
+		}
+
+		if st.Staged.rank() > d.Staged.rank() {
+			d.Staged = rollUp(st.Staged)
+		}
+		if st.Unstaged.rank() > d.Unstaged.rank() {
+			d.Unstaged = rollUp(st.Unstaged)
+		}
+
+		s.dirs[dir] = d
+	}
+}
+
+// rollUp returns the state a directory shows for a file in state st.
+func rollUp(st GitState) GitState {
+	if st == GitDeleted {
+		return GitModified
+	}
+
+	return st
+}
+
+func (s *gitRepoStatus) rel(abs string) string {
+	rel, err := filepath.Rel(s.top, abs)
+	if err != nil {
+		return ""
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// status returns the status of the entry at abs.
+func (s *gitRepoStatus) status(abs string) GitStatus {
+	rel := s.rel(abs)
+
+	if st, ok := s.files[rel]; ok {
+		return st
+	}
+
+	if st, ok := s.dirs[rel]; ok {
+		return st
+	}
+
+	for dir := rel; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		if s.ignoredDirs[dir] {
+			return GitStatus{GitUnmodified, GitIgnored}
+		}
+	}
+
+	return gitClean
+}
@@ -0,0 +1,224 @@
+package tree
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// makeGitRepo creates a repository with history in packfiles, staged,
+// unstaged, untracked, ignored and conflicted changes. The git command is only
+// used to build the fixture.
+func makeGitRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	write := func(name, content string) {
+		t.Helper()
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.name", "gotree")
+	git("config", "user.email", "gotree@example.com")
+	git("checkout", "-q", "-b", "main")
+
+	// Enough history for the packfile to hold deltas.
+	long := strings.Repeat("line\n", 200)
+	for i := 0; i < 5; i++ {
+		write("src/a.go", long+strings.Repeat("x", i))
+		write("src/deep/b.go", "b\n")
+		write("docs/c.md", "c\n")
+		write("rm.txt", "rm\n")
+		write("conflict.txt", "base\n")
+		write(".gitignore", "*.log\nbuild/\n")
+		git("add", "-A")
+		git("commit", "-q", "-m", "commit")
+	}
+	git("gc", "-q")
+
+	git("checkout", "-q", "-b", "other")
+	write("conflict.txt", "other\n")
+	git("commit", "-q", "-am", "other")
+	git("checkout", "-q", "main")
+	write("conflict.txt", "main\n")
+	git("commit", "-q", "-am", "main")
+
+	cmd := exec.Command("git", "merge", "-q", "other")
+	cmd.Dir = dir
+	cmd.Run()
+
+	write("src/a.go", long+"changed")
+	write("src/new.go", "new\n")
+	git("add", "src/new.go")
+	write("src/deep/b.go", "staged\n")
+	git("add", "src/deep/b.go")
+	write("src/deep/b.go", "staged and modified\n")
+	git("rm", "-q", "--cached", "rm.txt")
+	write("docs/d.md", "untracked\n")
+	write("x.log", "ignored\n")
+	write("build/out/bin", "ignored\n")
+
+	return dir
+}
+
+func TestGitStatus(t *testing.T) {
+	dir := makeGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	s := loadGitStatus(dir)
+	if s == nil {
+		t.Fatal("loadGitStatus returned nil inside a repository")
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"conflict.txt", "UU"},
+		{"docs", "-N"},
+		{"docs/c.md", "--"},
+		{"docs/d.md", "-N"},
+		{"rm.txt", "DN"},
+		{"src", "MM"},
+		{"src/a.go", "-M"},
+		{"src/deep", "MM"},
+		{"src/deep/b.go", "MM"},
+		{"src/new.go", "N-"},
+		{"x.log", "-I"},
+		{"build", "-I"},
+		{"build/out/bin", "-I"},
+		{".gitignore", "--"},
+	}
+
+	for _, tt := range tests {
+		if got := s.status(filepath.Join(dir, filepath.FromSlash(tt.path))).String(); got != tt.want {
+			t.Errorf("status(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGitStatusSubdir(t *testing.T) {
+	dir := makeGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	s := loadGitStatus(filepath.Join(dir, "src"))
+	if s == nil {
+		t.Fatal("loadGitStatus returned nil inside a repository")
+	}
+
+	if got, want := s.status(filepath.Join(dir, "src", "deep")).String(), "MM"; got != want {
+		t.Errorf("status(%q) = %q, want %q", "src/deep", got, want)
+	}
+
+	// Only the part of the repository below the directory is read.
+	for name := range s.files {
+		if !strings.HasPrefix(name, "src/") {
+			t.Errorf("got status of %q outside src", name)
+		}
+	}
+}
+
+func TestGitObjectCache(t *testing.T) {
+	var c gitObjectCache
+
+	big := make([]byte, gitObjectCacheSize/4)
+	for i := 0; i < 5; i++ {
+		c.add(gitObjectKey{off: int64(i)}, gitCachedObject{gitObjBlob, big})
+	}
+
+	// The oldest object is dropped to make room for the fifth.
+	if _, ok := c.get(gitObjectKey{off: 0}); ok {
+		t.Error("got the oldest object, want it dropped")
+	}
+	if _, ok := c.get(gitObjectKey{off: 4}); !ok {
+		t.Error("got no newest object")
+	}
+	if c.size > gitObjectCacheSize {
+		t.Errorf("got size %d, want at most %d", c.size, gitObjectCacheSize)
+	}
+
+	// Objects larger than a quarter of the cache are not kept.
+	c.add(gitObjectKey{off: 5}, gitCachedObject{gitObjBlob, make([]byte, gitObjectCacheSize/4+1)})
+	if _, ok := c.get(gitObjectKey{off: 5}); ok {
+		t.Error("got an object larger than a quarter of the cache")
+	}
+}
+
+func TestTreeGit(t *testing.T) {
+	dir := makeGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	if err := Tree(filepath.Join(dir, "src"), WithGit(true), WithColor(false), WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(dir, "src") + `
+├── [-M]  a.go
+├── [MM]  deep
+│   └── [MM]  b.go
+└── [N-]  new.go
+
+1 directories, 3 files
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	outside, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+
+	if err := ioutil.WriteFile(filepath.Join(outside, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := Tree(outside, WithGit(true), WithColor(false), WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	want = outside + `
+└── file
+
+0 directories, 1 files
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
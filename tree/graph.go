@@ -0,0 +1,269 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// categoryColors are the fill colors of the graph nodes of each category,
+// in the order of the categories.
+var categoryColors = []struct {
+	category Category
+	color    string
+}{
+	{CategoryLink, "#11a8cd"},
+	{CategoryBrokenLink, "#f14c4c"},
+	{CategoryDir, "#3b8eea"},
+	{CategoryExec, "#23d18b"},
+	{CategoryImmediate, "#f5f543"},
+	{CategoryImage, "#d670d6"},
+	{CategoryVideo, "#bc3fbc"},
+	{CategoryMusic, "#bc3fbc"},
+	{CategoryCrypto, "#29b8db"},
+	{CategoryDocument, "#0dbc79"},
+	{CategoryCompressed, "#cd3131"},
+	{CategoryTemp, "#999999"},
+	{CategoryCompiled, "#e5e510"},
+}
+
+func categoryColor(c Category) string {
+	for _, cc := range categoryColors {
+		if cc.category == c {
+			return cc.color
+		}
+	}
+
+	return ""
+}
+
+// graphNodes numbers the nodes of a graph and keeps track of the directory
+// each new node is attached to.
+type graphNodes struct {
+	next    int
+	parents []string
+	last    string
+}
+
+// root adds the node of a root, which has no parent.
+func (g *graphNodes) root() string {
+	g.parents = g.parents[:0]
+	g.last = g.add()
+	g.parents = append(g.parents, g.last)
+	return g.last
+}
+
+// child adds a node and returns it with the node of its directory.
+func (g *graphNodes) child() (id, parent string) {
+	g.last = g.add()
+	return g.last, g.parents[len(g.parents)-1]
+}
+
+func (g *graphNodes) add() string {
+	id := fmt.Sprintf("n%d", g.next)
+	g.next++
+	return id
+}
+
+func (g *graphNodes) enter() {
+	g.parents = append(g.parents, g.last)
+}
+
+func (g *graphNodes) leave() {
+	g.parents = g.parents[:len(g.parents)-1]
+}
+
+// graphLabel returns the uncolored text of a row as the text output prints it.
+func graphLabel(row *Row, root bool) string {
+	plain := *row
+	plain.colored = false
+
+	if root {
+		return plain.Root()
+	}
+
+	return plain.File()
+}
+
+// dotRenderer writes the tree as a Graphviz directed graph. Directories are
+// folder shaped nodes pointing to their entries.
+type dotRenderer struct {
+	out     io.Writer
+	started bool
+	nodes   graphNodes
+}
+
+// NewDOTRenderer returns a renderer which writes the tree to out as a
+// Graphviz DOT graph.
+func NewDOTRenderer(out io.Writer) Renderer {
+	return &dotRenderer{out: out}
+}
+
+func (d *dotRenderer) start() error {
+	if d.started {
+		return nil
+	}
+	d.started = true
+
+	_, err := io.WriteString(d.out, "digraph tree {\n  rankdir=LR;\n  node [shape=box, style=filled, fillcolor=\"#ffffff\", fontname=\"monospace\"];\n")
+	return err
+}
+
+func (d *dotRenderer) node(id string, row *Row, root bool) string {
+	attrs := "label=" + dotQuote(graphLabel(row, root))
+
+	if root || row.isDir() {
+		attrs += ", shape=folder"
+	}
+
+	if c := categoryColor(row.Category()); c != "" {
+		attrs += ", fillcolor=" + dotQuote(c)
+	}
+
+	return fmt.Sprintf("  %s [%s];\n", id, attrs)
+}
+
+func (d *dotRenderer) BeginRoot(root Row) error {
+	if err := d.start(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(d.out, d.node(d.nodes.root(), &root, true))
+	return err
+}
+
+func (d *dotRenderer) EnterDir(row Row) error {
+	d.nodes.enter()
+	return nil
+}
+
+func (d *dotRenderer) Entry(row Row) error {
+	id, parent := d.nodes.child()
+
+	_, err := fmt.Fprintf(d.out, "%s  %s -> %s;\n", d.node(id, &row, false), parent, id)
+	return err
+}
+
+func (d *dotRenderer) LeaveDir(row Row) error {
+	d.nodes.leave()
+	return nil
+}
+
+func (d *dotRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (d *dotRenderer) Finish(result Result) error {
+	if err := d.start(); err != nil {
+		return err
+	}
+
+	if result.NoReport {
+		_, err := io.WriteString(d.out, "}\n")
+		return err
+	}
+
+	_, err := fmt.Fprintf(d.out, "  // %s\n}\n", result)
+	return err
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidRenderer writes the tree as a Mermaid flowchart. The nodes are
+// styled with a class per category.
+type mermaidRenderer struct {
+	out     io.Writer
+	started bool
+	nodes   graphNodes
+	used    map[Category]bool
+}
+
+// NewMermaidRenderer returns a renderer which writes the tree to out as a
+// Mermaid flowchart.
+func NewMermaidRenderer(out io.Writer) Renderer {
+	return &mermaidRenderer{out: out, used: map[Category]bool{}}
+}
+
+func (m *mermaidRenderer) start() error {
+	if m.started {
+		return nil
+	}
+	m.started = true
+
+	_, err := io.WriteString(m.out, "graph LR\n")
+	return err
+}
+
+func (m *mermaidRenderer) node(id string, row *Row, root bool) string {
+	node := fmt.Sprintf("%s[\"%s\"]", id, mermaidEscape(graphLabel(row, root)))
+
+	if c := row.Category(); c != CategoryNone {
+		m.used[c] = true
+		node += ":::" + mermaidClass(c)
+	}
+
+	return node
+}
+
+func (m *mermaidRenderer) BeginRoot(root Row) error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(m.out, "  %s\n", m.node(m.nodes.root(), &root, true))
+	return err
+}
+
+func (m *mermaidRenderer) EnterDir(row Row) error {
+	m.nodes.enter()
+	return nil
+}
+
+func (m *mermaidRenderer) Entry(row Row) error {
+	id, parent := m.nodes.child()
+
+	_, err := fmt.Fprintf(m.out, "  %s --> %s\n", parent, m.node(id, &row, false))
+	return err
+}
+
+func (m *mermaidRenderer) LeaveDir(row Row) error {
+	m.nodes.leave()
+	return nil
+}
+
+func (m *mermaidRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (m *mermaidRenderer) Finish(result Result) error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, cc := range categoryColors {
+		if m.used[cc.category] {
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", mermaidClass(cc.category), cc.color)
+		}
+	}
+	if !result.NoReport {
+		fmt.Fprintf(&b, "  %%%% %s\n", result)
+	}
+
+	_, err := io.WriteString(m.out, b.String())
+	return err
+}
+
+// mermaidClass returns the class name of a category, which may not contain dashes.
+func mermaidClass(c Category) string {
+	return strings.Replace(string(c), "-", "_", -1)
+}
+
+// mermaidEscape replaces the characters which end or break a quoted Mermaid
+// label with entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("#", "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(s)
+}
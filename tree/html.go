@@ -0,0 +1,241 @@
+package tree
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Directory Tree</title>
+<style>
+body { background: #1e1e1e; color: #cccccc; font-family: monospace; }
+ul.tree, ul.tree ul { list-style: none; margin: 0; padding-left: 1.5em; }
+ul.tree { padding-left: 0; }
+details > summary { cursor: pointer; }
+details:not([open]) > summary::after { content: " …"; color: #666666; }
+a { color: inherit; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.status { color: #999999; white-space: pre; }
+.error { color: #f14c4c; }
+.note { color: #666666; }
+.dir { color: #3b8eea; font-weight: bold; }
+.exec { color: #23d18b; }
+.immediate { color: #f5f543; text-decoration: underline; }
+.image { color: #d670d6; }
+.video, .music { color: #bc3fbc; }
+.crypto { color: #29b8db; }
+.document { color: #0dbc79; }
+.compressed { color: #cd3131; }
+.temp { color: #666666; }
+.compiled { color: #e5e510; }
+.link { color: #11a8cd; }
+.broken-link { color: #f14c4c; }
+</style>
+</head>
+<body>
+<h1>Directory Tree</h1>
+<ul class="tree">
+`
+
+const htmlFooter = `</body>
+</html>
+`
+
+// htmlRenderer writes a self-contained HTML page with nested lists.
+// Directories are collapsible details elements.
+type htmlRenderer struct {
+	out     io.Writer
+	base    string
+	started bool
+	root    string
+	pending *Row
+}
+
+// NewHTMLRenderer returns a renderer which writes the tree to out as an HTML
+// page. Unless base is empty, every entry links to base joined with its path
+// relative to the root.
+func NewHTMLRenderer(out io.Writer, base string) Renderer {
+	return &htmlRenderer{out: out, base: base}
+}
+
+// href returns the link to the entry at p, or "" without links.
+func (h *htmlRenderer) href(p string, isDir bool) string {
+	if h.base == "" {
+		return ""
+	}
+
+	href := strings.TrimSuffix(h.base, "/") + "/"
+	if rel := filepath.ToSlash(h.relPath(p)); rel != "." {
+		href += escapePath(rel)
+		if isDir {
+			href += "/"
+		}
+	}
+
+	return href
+}
+
+func (h *htmlRenderer) start() error {
+	if h.started {
+		return nil
+	}
+	h.started = true
+
+	_, err := io.WriteString(h.out, htmlHeader)
+	return err
+}
+
+// flush writes the directory entry held back by Entry as a leaf, as it was
+// not descended into.
+func (h *htmlRenderer) flush() error {
+	if h.pending == nil {
+		return nil
+	}
+
+	row := h.pending
+	h.pending = nil
+
+	_, err := fmt.Fprintf(h.out, "<li>%s</li>\n", h.entry(row))
+	return err
+}
+
+func (h *htmlRenderer) BeginRoot(root Row) error {
+	if err := h.start(); err != nil {
+		return err
+	}
+
+	h.root = root.path
+
+	label := html.EscapeString(root.path)
+	if href := h.href(root.path, true); href != "" {
+		label = fmt.Sprintf(`<a class="dir" href="%s">%s</a>`, html.EscapeString(href), label)
+	}
+	if root.err != nil {
+		label += ` <span class="error">[error opening dir]</span>`
+	}
+
+	_, err := fmt.Fprintf(h.out, "<li><details open><summary>%s</summary>\n<ul>\n", label)
+	return err
+}
+
+func (h *htmlRenderer) EnterDir(row Row) error {
+	h.pending = nil
+
+	_, err := fmt.Fprintf(h.out, "<li><details open><summary>%s</summary>\n<ul>\n", h.entry(&row))
+	return err
+}
+
+func (h *htmlRenderer) Entry(row Row) error {
+	if err := h.flush(); err != nil {
+		return err
+	}
+
+	// A directory is written once it is known whether it is descended into.
+	if row.isDir() {
+		h.pending = &row
+		return nil
+	}
+
+	_, err := fmt.Fprintf(h.out, "<li>%s</li>\n", h.entry(&row))
+	return err
+}
+
+func (h *htmlRenderer) LeaveDir(row Row) error {
+	if err := h.flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(h.out, "</ul>\n</details></li>\n")
+	return err
+}
+
+func (h *htmlRenderer) EndRoot(root Row) error {
+	if err := h.flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(h.out, "</ul>\n</details></li>\n")
+	return err
+}
+
+func (h *htmlRenderer) Finish(result Result) error {
+	if err := h.start(); err != nil {
+		return err
+	}
+
+	if result.NoReport {
+		_, err := io.WriteString(h.out, "</ul>\n"+htmlFooter)
+		return err
+	}
+
+	_, err := fmt.Fprintf(h.out, "</ul>\n<p class=\"report\">%s</p>\n%s", html.EscapeString(result.String()), htmlFooter)
+	return err
+}
+
+// entry returns the markup of a row: its metadata columns, its name colored
+// by category and the markers of the text output.
+func (h *htmlRenderer) entry(row *Row) string {
+	plain := *row
+	plain.colored = false
+
+	var b strings.Builder
+
+	if status := plain.Status(); status != "" {
+		fmt.Fprintf(&b, `<span class="status">%s</span>`, html.EscapeString(status))
+	}
+
+	name := html.EscapeString(plain.displayName())
+	class := ""
+	if c := row.Category(); c != CategoryNone {
+		class = fmt.Sprintf(` class="%s"`, c)
+	}
+	if href := h.href(row.path, row.isDir()); href != "" {
+		name = fmt.Sprintf(`<a%s href="%s">%s</a>`, class, html.EscapeString(href), name)
+	} else if class != "" {
+		name = fmt.Sprintf(`<span%s>%s</span>`, class, name)
+	}
+	b.WriteString(name)
+
+	if row.isLink() {
+		fmt.Fprintf(&b, " -&gt; %s", html.EscapeString(row.linkTarget))
+		if row.recursive {
+			b.WriteString(` <span class="note">[recursive, not followed]</span>`)
+		}
+	}
+
+	if row.err != nil {
+		b.WriteString(` <span class="error">[error opening dir]</span>`)
+	}
+
+	if row.exceeded > 0 {
+		fmt.Fprintf(&b, ` <span class="note">[%d entries exceeds filelimit]</span>`, row.exceeded)
+	}
+
+	return b.String()
+}
+
+func (h *htmlRenderer) relPath(p string) string {
+	rel, err := filepath.Rel(h.root, p)
+	if err != nil {
+		return p
+	}
+
+	return rel
+}
+
+// escapePath escapes each segment of a slash separated path for use in a URL.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return strings.Join(segments, "/")
+}
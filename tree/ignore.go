@@ -0,0 +1,137 @@
+package tree
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single pattern of a gitignore style file.
+type ignoreRule struct {
+	pattern  string
+	base     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// parseIgnoreRule parses one line of an ignore file located in base.
+// It returns false for blank lines and comments.
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	rule := ignoreRule{base: base}
+
+	// Trailing spaces are ignored unless they are escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || line[0] == '#' {
+		return rule, false
+	}
+
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return rule, false
+	}
+
+	rule.pattern = line
+
+	return rule, true
+}
+
+// match reports whether the rule applies to p, a slash separated absolute path.
+func (r *ignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	prefix := r.base
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	if !strings.HasPrefix(p, prefix) {
+		return false
+	}
+
+	rel := p[len(prefix):]
+	if r.anchored {
+		return matchGlob(r.pattern, rel)
+	}
+
+	return matchGlob(r.pattern, path.Base(rel))
+}
+
+// ignoreMatcher holds the rules of one directory. Rules of the parent are
+// consulted only when none of the directory's own rules match.
+type ignoreMatcher struct {
+	parent *ignoreMatcher
+	rules  []ignoreRule
+}
+
+// ignored reports whether p is excluded. The last matching rule decides.
+func (m *ignoreMatcher) ignored(p string, isDir bool) bool {
+	for cur := m; cur != nil; cur = cur.parent {
+		for i := len(cur.rules) - 1; i >= 0; i-- {
+			if cur.rules[i].match(p, isDir) {
+				return !cur.rules[i].negate
+			}
+		}
+	}
+
+	return false
+}
+
+// load returns a matcher extending m with the rules of the ignore file at
+// file, whose patterns are relative to base. m is returned as is when the
+// file cannot be read or holds no rules.
+func (m *ignoreMatcher) load(file, base string) *ignoreMatcher {
+	f, err := os.Open(file)
+	if err != nil {
+		return m
+	}
+	defer f.Close()
+
+	base = filepath.ToSlash(base)
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) == 0 {
+		return m
+	}
+
+	return &ignoreMatcher{parent: m, rules: rules}
+}
+
+// loadDir extends m with every ignore file named in names found in dir.
+func (m *ignoreMatcher) loadDir(dir string, names []string) *ignoreMatcher {
+	for _, name := range names {
+		m = m.load(filepath.Join(dir, name), dir)
+	}
+
+	return m
+}
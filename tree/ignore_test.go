@@ -0,0 +1,55 @@
+package tree
+
+import "testing"
+
+func TestIgnoreMatcher(t *testing.T) {
+	lines := []string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"/top.txt",
+		"build/",
+		"docs/**/*.html",
+		"\\#hash",
+		"trailing   ",
+	}
+
+	var rules []ignoreRule
+	for _, l := range lines {
+		if r, ok := parseIgnoreRule(l, "/repo"); ok {
+			rules = append(rules, r)
+		}
+	}
+
+	parent := &ignoreMatcher{rules: rules}
+	child := &ignoreMatcher{parent: parent, rules: []ignoreRule{{pattern: "keep.log", base: "/repo/sub", negate: false}}}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/repo/a.log", false, true},
+		{"/repo/sub/a.log", false, true},
+		{"/repo/keep.log", false, false},
+		{"/repo/sub/keep.log", false, true},
+		{"/repo/top.txt", false, true},
+		{"/repo/sub/top.txt", false, false},
+		{"/repo/build", true, true},
+		{"/repo/sub/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/docs/index.html", false, true},
+		{"/repo/docs/a/b/index.html", false, true},
+		{"/repo/sub/docs/index.html", false, false},
+		{"/repo/#hash", false, true},
+		{"/repo/trailing", false, true},
+		{"/other/a.log", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := child.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
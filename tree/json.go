@@ -0,0 +1,187 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// jsonEntry is an entry of the JSON output. The metadata fields are nil for
+// the entries of a path list, which have none.
+type jsonEntry struct {
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Path      string       `json:"path"`
+	Mode      *uint32      `json:"mode,omitempty"`
+	UID       *uint32      `json:"uid,omitempty"`
+	User      string       `json:"user,omitempty"`
+	GID       *uint32      `json:"gid,omitempty"`
+	Group     string       `json:"group,omitempty"`
+	Size      *int64       `json:"size,omitempty"`
+	Allocated int64        `json:"allocated,omitempty"`
+	Target    string       `json:"target,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Exceeded  int          `json:"exceeded,omitempty"`
+	Git       string       `json:"git,omitempty"`
+	ModTime   *time.Time   `json:"mtime,omitempty"`
+	Children  []*jsonEntry `json:"children,omitempty"`
+}
+
+type jsonReport struct {
+	Type        string `json:"type"`
+	Directories int    `json:"directories"`
+	Files       int    `json:"files"`
+	Size        int64  `json:"size,omitempty"`
+	Allocated   int64  `json:"allocated,omitempty"`
+	Errors      int    `json:"errors,omitempty"`
+	Skipped     int    `json:"skipped,omitempty"`
+}
+
+func newJSONEntry(row *Row) *jsonEntry {
+	e := &jsonEntry{
+		Type:     fileType(row.mode),
+		Name:     row.name,
+		Path:     row.path,
+		Target:   row.linkTarget,
+		Exceeded: row.exceeded,
+	}
+
+	if !row.listed() {
+		mode, uid, gid, size, mtime := unixPerm(row.mode), row.userID(), row.groupID(), row.byteSize(), row.modTime()
+		e.Mode, e.UID, e.GID, e.Size, e.ModTime = &mode, &uid, &gid, &size, &mtime
+		e.User, e.Group = row.userName(), row.groupName()
+	}
+
+	if row.err != nil {
+		e.Error = row.err.Error()
+	}
+
+	if row.git {
+		e.Git = row.gitStatus.String()
+	}
+
+	if row.usage != nil {
+		size := row.usage.Size
+		e.Size = &size
+		e.Allocated = row.usage.Allocated
+	}
+
+	return e
+}
+
+func fileType(m os.FileMode) string {
+	switch {
+	case m.IsDir():
+		return "directory"
+	case m&os.ModeSymlink != 0:
+		return "link"
+	case m&os.ModeNamedPipe != 0:
+		return "fifo"
+	case m&os.ModeSocket != 0:
+		return "socket"
+	case m&os.ModeCharDevice != 0:
+		return "char"
+	case m&os.ModeDevice != 0:
+		return "block"
+	}
+
+	return "file"
+}
+
+// unixPerm returns the permission bits of m in their traditional octal positions.
+func unixPerm(m os.FileMode) uint32 {
+	perm := uint32(m.Perm())
+
+	if m&os.ModeSetuid != 0 {
+		perm |= 04000
+	}
+
+	if m&os.ModeSetgid != 0 {
+		perm |= 02000
+	}
+
+	if m&os.ModeSticky != 0 {
+		perm |= 01000
+	}
+
+	return perm
+}
+
+// jsonRenderer collects the tree and prints it as a JSON document once finished.
+type jsonRenderer struct {
+	out   io.Writer
+	roots []interface{}
+	dirs  []*jsonEntry
+	last  *jsonEntry
+}
+
+// NewJSONRenderer returns a renderer which writes the tree to out as a JSON document.
+func NewJSONRenderer(out io.Writer) Renderer {
+	return &jsonRenderer{out: out}
+}
+
+func (j *jsonRenderer) BeginRoot(root Row) error {
+	var e *jsonEntry
+	if root.name == "" {
+		e = &jsonEntry{Type: "directory", Path: root.path}
+		if root.err != nil {
+			e.Error = root.err.Error()
+		}
+	} else {
+		e = newJSONEntry(&root)
+	}
+	e.Name = root.path
+
+	j.roots = append(j.roots, e)
+	j.dirs = []*jsonEntry{e}
+
+	return nil
+}
+
+func (j *jsonRenderer) EnterDir(row Row) error {
+	j.dirs = append(j.dirs, j.last)
+	return nil
+}
+
+func (j *jsonRenderer) Entry(row Row) error {
+	parent := j.dirs[len(j.dirs)-1]
+	j.last = newJSONEntry(&row)
+	parent.Children = append(parent.Children, j.last)
+	return nil
+}
+
+func (j *jsonRenderer) LeaveDir(row Row) error {
+	j.dirs = j.dirs[:len(j.dirs)-1]
+	return nil
+}
+
+func (j *jsonRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (j *jsonRenderer) Finish(result Result) error {
+	if result.NoReport {
+		return j.write(j.roots)
+	}
+
+	report := jsonReport{Type: "report", Directories: result.Directories, Files: result.Files, Errors: result.Errors, Skipped: result.Skipped}
+	if result.Usage != nil {
+		report.Size = result.Usage.Size
+		report.Allocated = result.Usage.Allocated
+	}
+
+	return j.write(append(j.roots, report))
+}
+
+// write prints the document made of values.
+func (j *jsonRenderer) write(values []interface{}) error {
+	b, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(j.out, string(b))
+	return err
+}
@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"os"
+	"syscall"
+)
+
+// followedLink is a symbolic link to a directory which is walked into.
+// It keeps the information of the link itself but reports being a directory.
+type followedLink struct {
+	os.FileInfo
+	target os.FileInfo
+}
+
+func (f followedLink) IsDir() bool {
+	return true
+}
+
+func isFollowedLink(fi os.FileInfo) bool {
+	_, ok := fi.(followedLink)
+	return ok
+}
+
+// fileID returns the device and inode numbers identifying fi.
+func fileID(fi os.FileInfo) ([2]uint64, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return [2]uint64{}, false
+	}
+
+	return [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}, true
+}
+
+// followLink returns fi, or a followedLink if fi is a symbolic link to a
+// directory and links are to be followed.
+func (w *walker) followLink(path string, fi os.FileInfo) os.FileInfo {
+	if !w.follow || fi.Mode()&os.ModeSymlink == 0 {
+		return fi
+	}
+
+	target, err := os.Stat(path)
+	if err != nil || !target.IsDir() {
+		return fi
+	}
+
+	return followedLink{FileInfo: fi, target: target}
+}
+
+// dirID returns the identity of the directory fi refers to, following links.
+func dirID(fi os.FileInfo) ([2]uint64, bool) {
+	if l, ok := fi.(followedLink); ok {
+		return fileID(l.target)
+	}
+
+	return fileID(fi)
+}
@@ -0,0 +1,280 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MarkerStart and MarkerEnd delimit the block replaced by UpdateFile.
+	MarkerStart = "<!-- gotree:start -->"
+	MarkerEnd   = "<!-- gotree:end -->"
+)
+
+// markdownRenderer writes the text tree in a fenced code block.
+type markdownRenderer struct {
+	out     io.Writer
+	started bool
+}
+
+// NewMarkdownRenderer returns a renderer which writes the uncolored text
+// tree to out in a fenced Markdown code block.
+func NewMarkdownRenderer(out io.Writer) Renderer {
+	return &markdownRenderer{out: out}
+}
+
+func (m *markdownRenderer) start() error {
+	if m.started {
+		return nil
+	}
+	m.started = true
+
+	_, err := io.WriteString(m.out, "```\n")
+	return err
+}
+
+func (m *markdownRenderer) BeginRoot(root Row) error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	root.colored = false
+
+	_, err := fmt.Fprintln(m.out, root.Root())
+	return err
+}
+
+func (m *markdownRenderer) EnterDir(row Row) error {
+	return nil
+}
+
+func (m *markdownRenderer) Entry(row Row) error {
+	row.colored = false
+
+	_, err := fmt.Fprintln(m.out, row.Str())
+	return err
+}
+
+func (m *markdownRenderer) LeaveDir(row Row) error {
+	return nil
+}
+
+func (m *markdownRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (m *markdownRenderer) Finish(result Result) error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	if result.NoReport {
+		_, err := io.WriteString(m.out, "```\n")
+		return err
+	}
+
+	_, err := fmt.Fprintf(m.out, "\n%s\n```\n", result)
+	return err
+}
+
+// markdownListRenderer writes the tree as a nested bullet list.
+type markdownListRenderer struct {
+	out  io.Writer
+	base string
+	root string
+}
+
+// NewMarkdownListRenderer returns a renderer which writes the tree to out as
+// a nested Markdown list. Unless base is empty, every entry links to base
+// joined with its path relative to the root.
+func NewMarkdownListRenderer(out io.Writer, base string) Renderer {
+	return &markdownListRenderer{out: out, base: base}
+}
+
+// href returns the link to the entry at p, or "" without links.
+func (m *markdownListRenderer) href(p string, isDir bool) string {
+	if m.base == "" {
+		return ""
+	}
+
+	href := strings.TrimSuffix(m.base, "/") + "/"
+	if rel, err := filepath.Rel(m.root, p); err == nil && rel != "." {
+		href += escapePath(filepath.ToSlash(rel))
+		if isDir {
+			href += "/"
+		}
+	}
+
+	return href
+}
+
+func (m *markdownListRenderer) BeginRoot(root Row) error {
+	m.root = root.path
+
+	label := markdownEscape(root.path)
+	if href := m.href(root.path, true); href != "" {
+		label = fmt.Sprintf("[%s](%s)", label, href)
+	}
+	if root.err != nil {
+		label += ` \[error opening dir\]`
+	}
+
+	_, err := fmt.Fprintf(m.out, "- %s\n", label)
+	return err
+}
+
+func (m *markdownListRenderer) EnterDir(row Row) error {
+	return nil
+}
+
+func (m *markdownListRenderer) Entry(row Row) error {
+	_, err := fmt.Fprintf(m.out, "%s- %s\n", strings.Repeat("  ", int(row.level)), m.item(&row))
+	return err
+}
+
+func (m *markdownListRenderer) LeaveDir(row Row) error {
+	return nil
+}
+
+func (m *markdownListRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (m *markdownListRenderer) Finish(result Result) error {
+	if result.NoReport {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(m.out, "\n%s\n", result)
+	return err
+}
+
+// item returns the text of a list item: the metadata columns, the name and
+// the markers of the text output.
+func (m *markdownListRenderer) item(row *Row) string {
+	plain := *row
+	plain.colored = false
+
+	var b strings.Builder
+	b.WriteString(markdownEscape(plain.Status()))
+
+	name := markdownEscape(plain.displayName())
+	if href := m.href(row.path, row.isDir()); href != "" {
+		name = fmt.Sprintf("[%s](%s)", name, href)
+	}
+	b.WriteString(name)
+
+	if row.isLink() {
+		b.WriteString(" -> " + markdownEscape(row.linkTarget))
+		if row.recursive {
+			b.WriteString(`  \[recursive, not followed\]`)
+		}
+	}
+
+	if row.err != nil {
+		b.WriteString(`  \[error opening dir\]`)
+	}
+
+	if row.exceeded > 0 {
+		fmt.Fprintf(&b, `  \[%d entries exceeds filelimit\]`, row.exceeded)
+	}
+
+	return b.String()
+}
+
+// markdownEscape escapes the characters of s which Markdown would interpret
+// inline.
+func markdownEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+	).Replace(s)
+}
+
+// UpdateFile renders roots like Trees and replaces the content between the
+// first MarkerStart line of the file at path and the MarkerEnd line following
+// it with the output, leaving the rest of the file, including any later
+// marked block, untouched. The file is replaced at once by renaming a
+// temporary file over it, and is not written if the rendering fails, except
+// that ErrPartial is returned after writing.
+func UpdateFile(path string, roots []string, opts ...Option) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	start := bytes.Index(content, []byte(MarkerStart))
+	if start < 0 {
+		return fmt.Errorf("%s: no %s marker", path, MarkerStart)
+	}
+	start += len(MarkerStart)
+
+	end := bytes.Index(content[start:], []byte(MarkerEnd))
+	if end < 0 {
+		return fmt.Errorf("%s: no %s marker after %s", path, MarkerEnd, MarkerStart)
+	}
+	end += start
+
+	var buf bytes.Buffer
+	buf.Write(content[:start])
+	buf.WriteString("\n")
+
+	rendered := Trees(roots, append(opts, WithColor(false), WithWriter(&buf))...)
+	if rendered != nil && !errors.Is(rendered, ErrPartial) {
+		return rendered
+	}
+
+	buf.Write(content[end:])
+
+	if err := replaceFile(path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	return rendered
+}
+
+// replaceFile replaces the content of the file at path with data, keeping
+// its permissions. The data is written to a temporary file in the same
+// directory which is then renamed over path, so that the file is never left
+// partially written. A symbolic link is replaced at its target.
+func replaceFile(path string, data []byte) (err error) {
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+package tree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	readme := filepath.Join(dir, "README.md")
+	// Only the first block is replaced.
+	second := MarkerStart + "\nkept\n" + MarkerEnd + "\n"
+	content := "# Layout\n\n" + MarkerStart + "\nstale\n" + MarkerEnd + "\n\nMore text.\n" + second
+	if err := ioutil.WriteFile(readme, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateFile(readme, []string{TMP_DIR + "/foo"}, WithLevel(1), WithFormat(OutputMarkdown)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Layout\n\n" + MarkerStart + "\n```\ntmp/foo\n├── bar\n├── quux\n└── qux\n\n1 directories, 2 files\n```\n" + MarkerEnd + "\n\nMore text.\n" + second
+	if diff := cmp.Diff(string(got), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	if fi := mustLstat(t, readme); fi.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, want the original mode", fi.Mode())
+	}
+
+	// The temporary file is renamed over the original.
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got %d files, want only the updated file", len(files))
+	}
+
+	// A link is updated at its target and stays a link.
+	link := filepath.Join(dir, "LINK.md")
+	if err := os.Symlink("README.md", link); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateFile(link, []string{TMP_DIR + "/foo"}, WithLevel(1), WithFormat(OutputMarkdown)); err != nil {
+		t.Fatal(err)
+	}
+	if fi := mustLstat(t, link); fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("got mode %v, want a link", fi.Mode())
+	}
+
+	if err := ioutil.WriteFile(readme, []byte("# Layout\n"+MarkerEnd+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateFile(readme, []string{TMP_DIR}); err == nil {
+		t.Error("got no error for a file without start marker")
+	}
+}
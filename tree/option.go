@@ -0,0 +1,432 @@
+package tree
+
+import "io"
+
+// Option configures how Tree walks and renders a directory.
+type Option interface {
+	apply(*walker)
+}
+
+type coloredOption bool
+
+func (c coloredOption) apply(w *walker) {
+	w.colored = bool(c)
+}
+
+// WithColor enables or disables ANSI colors in the output.
+func WithColor(colored bool) Option {
+	return coloredOption(colored)
+}
+
+type themeOption struct {
+	theme *Theme
+}
+
+func (t themeOption) apply(w *walker) {
+	w.theme = t.theme
+}
+
+// WithTheme colors the output with theme, falling back to the built-in
+// colors for anything it does not define. It has no effect without color.
+func WithTheme(theme *Theme) Option {
+	return themeOption{theme}
+}
+
+type levelOption uint
+
+func (l levelOption) apply(w *walker) {
+	w.level = uint(l)
+}
+
+// WithLevel limits the walk to the given number of directory levels.
+func WithLevel(level uint) Option {
+	return levelOption(level)
+}
+
+type permissionOption bool
+
+func (p permissionOption) apply(w *walker) {
+	w.permission = bool(p)
+}
+
+// WithPermission prints the permission bits of each entry.
+func WithPermission(permission bool) Option {
+	return permissionOption(permission)
+}
+
+type uidOption bool
+
+func (u uidOption) apply(w *walker) {
+	w.uid = bool(u)
+}
+
+// WithUID prints the owner name or UID of each entry.
+func WithUID(uid bool) Option {
+	return uidOption(uid)
+}
+
+type gidOption bool
+
+func (g gidOption) apply(w *walker) {
+	w.gid = bool(g)
+}
+
+// WithGID prints the group name or GID of each entry.
+func WithGID(gid bool) Option {
+	return gidOption(gid)
+}
+
+type sizeOption bool
+
+func (s sizeOption) apply(w *walker) {
+	w.size = bool(s)
+}
+
+// WithSize prints the size of each file.
+func WithSize(size bool) Option {
+	return sizeOption(size)
+}
+
+type includeDotOption bool
+
+func (i includeDotOption) apply(w *walker) {
+	w.includeDot = bool(i)
+}
+
+// WithIncludeDot lists entries whose name begins with a dot.
+func WithIncludeDot(includeDot bool) Option {
+	return includeDotOption(includeDot)
+}
+
+type datetimeOption bool
+
+func (dt datetimeOption) apply(w *walker) {
+	w.datetime = bool(dt)
+}
+
+// WithDatetime prints the modification time of each entry.
+func WithDatetime(datetime bool) Option {
+	return datetimeOption(datetime)
+}
+
+type gitOption bool
+
+func (g gitOption) apply(w *walker) {
+	w.git = bool(g)
+}
+
+// WithGit prints the git status of each entry. Directories show the most
+// important status of their contents. Entries outside a repository have no status.
+func WithGit(git bool) Option {
+	return gitOption(git)
+}
+
+type fullPathOption bool
+
+func (f fullPathOption) apply(w *walker) {
+	w.fullPath = bool(f)
+}
+
+// WithFullPath prints the path of each entry, starting from the root as given.
+func WithFullPath(fullPath bool) Option {
+	return fullPathOption(fullPath)
+}
+
+type absPathOption bool
+
+func (a absPathOption) apply(w *walker) {
+	w.absolute = bool(a)
+}
+
+// WithAbsPath prints the absolute path of each entry, including the root.
+func WithAbsPath(absPath bool) Option {
+	return absPathOption(absPath)
+}
+
+type noIndentOption bool
+
+func (n noIndentOption) apply(w *walker) {
+	w.noIndent = bool(n)
+}
+
+// WithNoIndent prints the entries without indentation and connectors.
+func WithNoIndent(noIndent bool) Option {
+	return noIndentOption(noIndent)
+}
+
+type noReportOption bool
+
+func (n noReportOption) apply(w *walker) {
+	w.noReport = bool(n)
+}
+
+// WithNoReport leaves the totals out of the output, so that with WithFullPath
+// and WithNoIndent it is a plain list of paths.
+func WithNoReport(noReport bool) Option {
+	return noReportOption(noReport)
+}
+
+type formatOption OutputFormat
+
+func (f formatOption) apply(w *walker) {
+	w.format = OutputFormat(f)
+}
+
+// WithFormat selects the built-in renderer.
+func WithFormat(format OutputFormat) Option {
+	return formatOption(format)
+}
+
+type markdownLinksOption string
+
+func (m markdownLinksOption) apply(w *walker) {
+	w.markdownBase = string(m)
+}
+
+// WithMarkdownLinks links every entry of the Markdown list to base joined with its path
+// relative to the root.
+func WithMarkdownLinks(base string) Option {
+	return markdownLinksOption(base)
+}
+
+type pathListOption struct {
+	root string
+	list *PathList
+}
+
+func (p pathListOption) apply(w *walker) {
+	w.pathLists[p.root] = p.list
+}
+
+// WithPathList renders list as the hierarchy below root instead of reading root from the
+// file system. Only the names are shown, and options which need the file system such as
+// WithDU, WithGit or WithGitignore do not apply to it.
+func WithPathList(root string, list *PathList) Option {
+	return pathListOption{root, list}
+}
+
+type htmlLinksOption string
+
+func (h htmlLinksOption) apply(w *walker) {
+	w.htmlBase = string(h)
+}
+
+// WithHTMLLinks links every entry of the HTML page to base joined with its path relative
+// to the root. Without it the page has no links.
+func WithHTMLLinks(base string) Option {
+	return htmlLinksOption(base)
+}
+
+type patternOption []string
+
+func (p patternOption) apply(w *walker) {
+	w.patterns = append(w.patterns, p...)
+}
+
+// WithPattern lists only the files matching one of patterns.
+// Directories are still listed unless they are ignored.
+func WithPattern(patterns ...string) Option {
+	return patternOption(patterns)
+}
+
+type ignorePatternOption []string
+
+func (i ignorePatternOption) apply(w *walker) {
+	w.ignorePatterns = append(w.ignorePatterns, i...)
+}
+
+// WithIgnorePattern omits the files and directories matching one of patterns.
+func WithIgnorePattern(patterns ...string) Option {
+	return ignorePatternOption(patterns)
+}
+
+type pruneOption bool
+
+func (p pruneOption) apply(w *walker) {
+	w.prune = bool(p)
+}
+
+// WithPrune omits directories which have nothing left to list after filtering.
+func WithPrune(prune bool) Option {
+	return pruneOption(prune)
+}
+
+type dirsOnlyOption bool
+
+func (d dirsOnlyOption) apply(w *walker) {
+	w.dirsOnly = bool(d)
+}
+
+// WithDirsOnly lists directories only. The files left out are counted as skipped.
+func WithDirsOnly(dirsOnly bool) Option {
+	return dirsOnlyOption(dirsOnly)
+}
+
+type fileLimitOption int
+
+func (f fileLimitOption) apply(w *walker) {
+	w.fileLimit = int(f)
+}
+
+// WithFileLimit does not descend into directories with more than limit
+// entries. The entries left out are counted as skipped. 0 means no limit.
+func WithFileLimit(limit int) Option {
+	return fileLimitOption(limit)
+}
+
+type gitignoreOption bool
+
+func (g gitignoreOption) apply(w *walker) {
+	w.gitignore = bool(g)
+	if w.gitignore && !contains(w.ignoreFiles, ".gitignore") {
+		w.ignoreFiles = append([]string{".gitignore"}, w.ignoreFiles...)
+	}
+}
+
+// WithGitignore omits the entries ignored by git: the .gitignore files of
+// every directory, .git/info/exclude and the global excludes file.
+func WithGitignore(gitignore bool) Option {
+	return gitignoreOption(gitignore)
+}
+
+type ignoreFileOption []string
+
+func (i ignoreFileOption) apply(w *walker) {
+	for _, name := range i {
+		if !contains(w.ignoreFiles, name) {
+			w.ignoreFiles = append(w.ignoreFiles, name)
+		}
+	}
+}
+
+// WithIgnoreFile reads ignore files with the given names, such as
+// .dockerignore, in every directory. They follow the gitignore syntax.
+func WithIgnoreFile(names ...string) Option {
+	return ignoreFileOption(names)
+}
+
+type sortOption SortOrder
+
+func (s sortOption) apply(w *walker) {
+	w.sortOrder = SortOrder(s)
+}
+
+// WithSort sets the order of the entries within a directory. Defaults to SortName.
+func WithSort(order SortOrder) Option {
+	return sortOption(order)
+}
+
+type reverseOption bool
+
+func (r reverseOption) apply(w *walker) {
+	w.reverse = bool(r)
+}
+
+// WithReverse reverses the sort order.
+func WithReverse(reverse bool) Option {
+	return reverseOption(reverse)
+}
+
+type dirsFirstOption bool
+
+func (d dirsFirstOption) apply(w *walker) {
+	w.dirsFirst = bool(d)
+	if w.dirsFirst {
+		w.filesFirst = false
+	}
+}
+
+// WithDirsFirst lists directories before files.
+func WithDirsFirst(dirsFirst bool) Option {
+	return dirsFirstOption(dirsFirst)
+}
+
+type filesFirstOption bool
+
+func (f filesFirstOption) apply(w *walker) {
+	w.filesFirst = bool(f)
+	if w.filesFirst {
+		w.dirsFirst = false
+	}
+}
+
+// WithFilesFirst lists files before directories.
+func WithFilesFirst(filesFirst bool) Option {
+	return filesFirstOption(filesFirst)
+}
+
+type duOption bool
+
+func (d duOption) apply(w *walker) {
+	w.du = bool(d)
+}
+
+// WithDU prints the accumulated size of each directory and the total size.
+// Files with several hard links are counted once.
+func WithDU(du bool) Option {
+	return duOption(du)
+}
+
+type sizeFormatOption SizeFormat
+
+func (s sizeFormatOption) apply(w *walker) {
+	w.sizeFormat = SizeFormat(s)
+}
+
+// WithSizeFormat sets the format sizes are printed in. Defaults to SizeSI.
+func WithSizeFormat(format SizeFormat) Option {
+	return sizeFormatOption(format)
+}
+
+type followOption bool
+
+func (f followOption) apply(w *walker) {
+	w.follow = bool(f)
+}
+
+// WithFollow descends into symbolic links to directories. Links leading
+// back to a directory being walked are not followed.
+func WithFollow(follow bool) Option {
+	return followOption(follow)
+}
+
+type parallelOption int
+
+func (p parallelOption) apply(w *walker) {
+	w.parallel = int(p)
+}
+
+// WithParallel reads the subtrees ahead of the walk with up to workers
+// concurrent reads, which pays off when reads wait on the file system, as on
+// network file systems or with a cold cache. The output stays in tree order.
+// Values below 2 walk sequentially.
+func WithParallel(workers int) Option {
+	return parallelOption(workers)
+}
+
+type writerOption struct {
+	out io.Writer
+}
+
+func (wr writerOption) apply(w *walker) {
+	w.out = wr.out
+}
+
+// WithWriter sets the destination of the built-in renderers. Defaults to os.Stdout.
+func WithWriter(out io.Writer) Option {
+	return writerOption{out}
+}
+
+type rendererOption struct {
+	renderer Renderer
+}
+
+func (r rendererOption) apply(w *walker) {
+	w.renderer = r.renderer
+}
+
+// WithRenderer replaces the built-in renderers. The renderer is responsible for its
+// own output, so WithWriter, WithFormat, WithJSON, WithXML and WithHTML have no effect.
+func WithRenderer(renderer Renderer) Option {
+	return rendererOption{renderer}
+}
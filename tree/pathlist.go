@@ -0,0 +1,137 @@
+package tree
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PathList is a hierarchy given as a list of paths, such as the output of
+// git ls-files or find. It is rendered in place of the file system with
+// WithPathList.
+type PathList struct {
+	// dirs maps the slash separated path of every directory, "." for the
+	// top, to its entries and whether they are directories.
+	dirs map[string]map[string]bool
+	// names holds the entries of every directory in the order they were
+	// added, which is kept when the entries are not sorted.
+	names map[string][]string
+}
+
+// NewPathList returns the hierarchy made of paths.
+func NewPathList(paths ...string) *PathList {
+	l := &PathList{
+		dirs:  map[string]map[string]bool{".": {}},
+		names: map[string][]string{},
+	}
+	for _, p := range paths {
+		l.Add(p)
+	}
+
+	return l
+}
+
+// ReadPathList reads a path list from r. Paths are separated by NUL
+// characters if there are any, by newlines otherwise.
+func ReadPathList(r io.Reader) (*PathList, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
+	}
+
+	l := NewPathList()
+	for _, p := range strings.Split(string(data), sep) {
+		l.Add(strings.TrimSuffix(p, "\r"))
+	}
+
+	return l, nil
+}
+
+// Add adds the entry at p and the directories leading to it. A trailing
+// slash makes the entry a directory, as does adding an entry below it.
+// Paths are taken relative to the top, even if they are absolute.
+func (l *PathList) Add(p string) {
+	p = filepath.ToSlash(p)
+	isDir := strings.HasSuffix(p, "/")
+
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return
+	}
+
+	dir := "."
+	names := strings.Split(p, "/")
+	for i, name := range names {
+		entries := l.dirs[dir]
+		if _, ok := entries[name]; !ok {
+			l.names[dir] = append(l.names[dir], name)
+		}
+
+		if i < len(names)-1 || isDir {
+			entries[name] = true
+		} else if _, ok := entries[name]; !ok {
+			entries[name] = false
+		}
+
+		dir = path.Join(dir, name)
+		if entries[name] && l.dirs[dir] == nil {
+			l.dirs[dir] = map[string]bool{}
+		}
+	}
+}
+
+// readDir returns the entries of the directory at the slash separated path
+// dir, in the order they were added.
+func (l *PathList) readDir(dir string) []os.FileInfo {
+	var files []os.FileInfo
+	for _, name := range l.names[dir] {
+		files = append(files, listedFile{name: name, dir: l.dirs[dir][name]})
+	}
+
+	return files
+}
+
+// listedFile is an entry of a path list. Only its name and whether it is a
+// directory are known.
+type listedFile struct {
+	name string
+	dir  bool
+}
+
+func (f listedFile) Name() string {
+	return f.name
+}
+
+func (f listedFile) Size() int64 {
+	return 0
+}
+
+func (f listedFile) Mode() os.FileMode {
+	if f.dir {
+		return os.ModeDir | 0755
+	}
+
+	return 0644
+}
+
+func (f listedFile) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (f listedFile) IsDir() bool {
+	return f.dir
+}
+
+func (f listedFile) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,71 @@
+package tree
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadPathList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string][]string
+	}{
+		{
+			name:  "newlines",
+			input: "src/main.go\r\n./README.md\n\nsrc/\ndocs/\n",
+			want: map[string][]string{
+				".":    {"README.md", "docs/", "src/"},
+				"docs": nil,
+				"src":  {"main.go"},
+			},
+		},
+		{
+			name:  "NUL",
+			input: "a b/c\nd\x00/abs/e\x00../up\x00",
+			want: map[string][]string{
+				".":   {"a b/", "abs/", "up"},
+				"a b": {"c\nd"},
+				"abs": {"e"},
+			},
+		},
+		{
+			name:  "file becomes directory",
+			input: "a\na/b\n",
+			want: map[string][]string{
+				".": {"a/"},
+				"a": {"b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := ReadPathList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string][]string{}
+			for dir := range l.dirs {
+				var names []string
+				for _, fi := range l.readDir(dir) {
+					name := fi.Name()
+					if fi.IsDir() {
+						name += "/"
+					}
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				got[dir] = names
+			}
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
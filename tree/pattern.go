@@ -0,0 +1,157 @@
+package tree
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// matchPatterns reports whether an entry matches any of patterns.
+// Each pattern may hold several globs separated by "|". Globs containing
+// a "/" are matched against rel, the slash separated path from the root,
+// and the others against the entry name.
+func matchPatterns(patterns []string, name, rel string) bool {
+	for _, p := range patterns {
+		for _, glob := range strings.Split(p, "|") {
+			if glob == "" {
+				continue
+			}
+
+			subject := name
+			if strings.Contains(glob, "/") {
+				subject = rel
+			}
+
+			if matchGlob(glob, subject) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchGlob reports whether name matches the shell glob pattern.
+// "*", "?" and "[...]" never match "/", while "**" matches any number of
+// path segments, and "**/" also matches none.
+func matchGlob(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			if strings.HasPrefix(pattern, "**") {
+				rest := strings.TrimLeft(pattern, "*")
+				if strings.HasPrefix(rest, "/") && matchGlob(rest[1:], name) {
+					return true
+				}
+
+				for i := 0; i <= len(name); i++ {
+					if matchGlob(rest, name[i:]) {
+						return true
+					}
+				}
+
+				return false
+			}
+
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(rest, name[i:]) {
+					return true
+				}
+
+				if i < len(name) && name[i] == '/' {
+					break
+				}
+			}
+
+			return false
+		case '?':
+			if name == "" || name[0] == '/' {
+				return false
+			}
+
+			_, n := utf8.DecodeRuneInString(name)
+			name = name[n:]
+			pattern = pattern[1:]
+		case '[':
+			if name == "" || name[0] == '/' {
+				return false
+			}
+
+			r, n := utf8.DecodeRuneInString(name)
+			matched, width, ok := matchClass(pattern, r)
+			if !ok {
+				// An unterminated class is an ordinary "[".
+				if name[0] != '[' {
+					return false
+				}
+
+				name = name[1:]
+				pattern = pattern[1:]
+				continue
+			}
+
+			if !matched {
+				return false
+			}
+
+			name = name[n:]
+			pattern = pattern[width:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+		default:
+			pr, pn := utf8.DecodeRuneInString(pattern)
+			r, n := utf8.DecodeRuneInString(name)
+			if name == "" || pr != r {
+				return false
+			}
+
+			name = name[n:]
+			pattern = pattern[pn:]
+		}
+	}
+
+	return name == ""
+}
+
+// matchClass matches r against the character class at the start of pattern.
+// It returns the number of bytes the class occupies and whether it is well formed.
+func matchClass(pattern string, r rune) (bool, int, bool) {
+	i := 1
+	negated := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negated = true
+		i++
+	}
+
+	matched := false
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negated, i + 1, true
+		}
+		first = false
+
+		lo, n := utf8.DecodeRuneInString(pattern[i:])
+		if lo == '\\' && i+n < len(pattern) {
+			i += n
+			lo, n = utf8.DecodeRuneInString(pattern[i:])
+		}
+		i += n
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, n = utf8.DecodeRuneInString(pattern[i+1:])
+			i += 1 + n
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0, false
+}
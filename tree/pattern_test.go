@@ -0,0 +1,57 @@
+package tree
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.goo", false},
+		{"*.go", "cmd/main.go", false},
+		{"?ain.go", "main.go", true},
+		{"[a-m]ain.go", "main.go", true},
+		{"[!a-m]ain.go", "main.go", false},
+		{"[]]", "]", true},
+		{"[", "[", true},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/gotree/main.go", true},
+		{"cmd/**", "cmd/gotree/main.go", true},
+		{"cmd/**", "cmd", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/*/b", "a/x/y/b", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPatterns(t *testing.T) {
+	patterns := []string{"*.md|*.o", "foo/*"}
+
+	tests := []struct {
+		name string
+		rel  string
+		want bool
+	}{
+		{"README.md", "01/README.md", true},
+		{"compiled.o", "01/compiled.o", true},
+		{"qux", "foo/qux", true},
+		{"baz", "foo/bar/baz", false},
+		{"exec", "01/exec", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPatterns(patterns, tt.name, tt.rel); got != tt.want {
+			t.Errorf("matchPatterns(%q, %q) = %v, want %v", tt.name, tt.rel, got, tt.want)
+		}
+	}
+}
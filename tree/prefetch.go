@@ -0,0 +1,259 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// prefetchLimit is the number of directory reads per worker which may be
+// held ahead of the walk, bounding the memory taken by their entries.
+const prefetchLimit = 256
+
+// prefetcher reads directories ahead of the walk with a pool of workers.
+// Once a directory is read, its subdirectories are read in turn, so that
+// whole subtrees are ready when the walk reaches them. The walk, the disk
+// usage and the pruning share the results, which are kept until the walk
+// leaves the directory.
+type prefetcher struct {
+	workers int
+	// maxDepth is the depth of the directories which are not read, as they
+	// are beyond the level limit.
+	maxDepth uint
+	// crawl tells whether the workers read the subdirectories of what they
+	// read. It is off when ignore rules may leave directories out, and only
+	// the directories the walk lists are read ahead then.
+	crawl bool
+	// hidden tells whether hidden directories are read.
+	hidden bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []prefetchJob
+	reads   map[string]*dirRead
+	started bool
+	closed  bool
+}
+
+// prefetchJob is a queued directory read.
+type prefetchJob struct {
+	dir string
+	r   *dirRead
+}
+
+// dirRead is a directory read which is queued, running or done.
+type dirRead struct {
+	done     chan struct{}
+	depth    uint
+	files    []os.FileInfo
+	err      error
+	released bool
+}
+
+func newPrefetcher(workers int, maxDepth uint, crawl, hidden bool) *prefetcher {
+	p := &prefetcher{
+		workers:  workers,
+		maxDepth: maxDepth,
+		crawl:    crawl,
+		hidden:   hidden,
+		reads:    map[string]*dirRead{},
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	return p
+}
+
+// schedule queues the read of dir, at depth below the root.
+func (p *prefetcher) schedule(dir string, depth uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scheduleLocked(dir, depth)
+}
+
+func (p *prefetcher) scheduleLocked(dir string, depth uint) {
+	if p.closed || depth >= p.maxDepth || len(p.reads) >= p.workers*prefetchLimit {
+		return
+	}
+
+	if _, ok := p.reads[dir]; ok {
+		return
+	}
+
+	if !p.started {
+		p.started = true
+		for i := 0; i < p.workers; i++ {
+			go p.work()
+		}
+	}
+
+	r := &dirRead{done: make(chan struct{}), depth: depth}
+	p.reads[dir] = r
+	p.queue = append(p.queue, prefetchJob{dir, r})
+	p.cond.Signal()
+}
+
+// work reads the queued directories until the prefetcher is closed. The
+// latest queued directory is read first, which follows the depth first
+// order of the walk.
+func (p *prefetcher) work() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		for len(p.queue) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if p.closed {
+			return
+		}
+
+		job := p.queue[len(p.queue)-1]
+		p.queue = p.queue[:len(p.queue)-1]
+
+		dir, r := job.dir, job.r
+		if r.released {
+			close(r.done)
+			continue
+		}
+
+		p.mu.Unlock()
+		files, err := readDirRaw(dir)
+		p.mu.Lock()
+
+		r.files, r.err = files, err
+		if err == nil && !r.released {
+			p.crawlLocked(dir, r)
+		}
+		close(r.done)
+	}
+}
+
+// crawlLocked queues the subdirectories of dir, which was read as r.
+func (p *prefetcher) crawlLocked(dir string, r *dirRead) {
+	if !p.crawl {
+		return
+	}
+
+	for _, file := range r.files {
+		name := file.Name()
+		if !file.IsDir() || (!p.hidden && name[:1] == ".") {
+			continue
+		}
+
+		p.scheduleLocked(filepath.Join(dir, name), r.depth+1)
+	}
+}
+
+// read returns the entries of dir, at depth below the root, waiting for the
+// background read if one was queued and reading dir directly otherwise. The
+// returned slice is shared and must not be modified.
+func (p *prefetcher) read(dir string, depth uint) ([]os.FileInfo, error) {
+	p.mu.Lock()
+	r, ok := p.reads[dir]
+	p.mu.Unlock()
+
+	if ok {
+		<-r.done
+		return r.files, r.err
+	}
+
+	files, err := readDirRaw(dir)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The result is kept for the other readers of dir if there is room.
+	if !p.closed && len(p.reads) < p.workers*prefetchLimit {
+		r = &dirRead{done: make(chan struct{}), depth: depth, files: files, err: err}
+		close(r.done)
+		p.reads[dir] = r
+
+		if err == nil {
+			p.crawlLocked(dir, r)
+		}
+	}
+
+	return files, err
+}
+
+// release drops the results of dir and of everything read below it, once
+// the walk is done with them.
+func (p *prefetcher) release(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.releaseLocked(dir)
+}
+
+func (p *prefetcher) releaseLocked(dir string) {
+	r, ok := p.reads[dir]
+	if !ok {
+		return
+	}
+
+	r.released = true
+	delete(p.reads, dir)
+
+	// Followed links are read through their own path, so every entry is
+	// looked up, not only directories.
+	for _, file := range r.files {
+		p.releaseLocked(filepath.Join(dir, file.Name()))
+	}
+}
+
+// close stops the workers. Reads which are not done yet are abandoned.
+func (p *prefetcher) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+// readDirRaw returns the entries of dir in directory order. It is a variable
+// so that the benchmarks can simulate a slow file system.
+var readDirRaw = func(dir string) ([]os.FileInfo, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Readdir(-1)
+}
+
+// prefetchDepth returns the depth of dir below the root.
+func (w *walker) prefetchDepth(dir string) uint {
+	rel := w.relPath(dir)
+	if rel == "." {
+		return 0
+	}
+
+	depth := uint(1)
+	for _, c := range rel {
+		if c == '/' {
+			depth++
+		}
+	}
+
+	return depth
+}
+
+// prefetchListed queues the reads of the directories among entries, which
+// are listed in dir at depth below the root.
+func (w *walker) prefetchListed(dir string, entries []os.FileInfo, depth uint) {
+	for _, file := range entries {
+		if !file.IsDir() {
+			continue
+		}
+
+		if w.follow {
+			if id, ok := dirID(file); ok && w.visiting[id] {
+				continue
+			}
+		}
+
+		w.prefetcher.schedule(filepath.Join(dir, file.Name()), depth+1)
+	}
+}
@@ -0,0 +1,194 @@
+package tree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// makeSyntheticTree creates depth levels of fanout directories under dir,
+// each holding files empty files.
+func makeSyntheticTree(dir string, depth, fanout, files int) error {
+	for i := 0; i < files; i++ {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("file%d", i)))
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+
+	if depth == 0 {
+		return nil
+	}
+
+	for i := 0; i < fanout; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(sub, 0755); err != nil {
+			return err
+		}
+
+		if err := makeSyntheticTree(sub, depth-1, fanout, files); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestTreeParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := makeSyntheticTree(dir, 3, 4, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range []string{TMP_DIR, dir} {
+		for _, opts := range [][]Option{
+			{},
+			{WithLevel(2)},
+			{WithPrune(true), WithPattern("file1")},
+			{WithDU(true), WithSort(SortVersion), WithReverse(true)},
+			{WithIgnorePattern("dir1"), WithIncludeDot(true), WithFollow(true)},
+			{WithLevel(1), WithDU(true), WithPrune(true)},
+		} {
+			want := uncoloredTree(t, root, opts...)
+			got := uncoloredTree(t, root, append(opts, WithParallel(8))...)
+
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestPrefetcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := makeSyntheticTree(dir, 2, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".hidden"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPrefetcher(4, 2, true, false)
+	defer p.close()
+
+	if _, err := p.read(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The subdirectories are queued as soon as the root is read, hidden ones
+	// and those beyond the depth limit left out.
+	p.mu.Lock()
+	queued := len(p.reads)
+	_, hidden := p.reads[filepath.Join(dir, ".hidden")]
+	p.mu.Unlock()
+
+	if queued != 4 {
+		t.Errorf("got %d directories read, want the root and its 3 subdirectories", queued)
+	}
+	if hidden {
+		t.Error("got the hidden directory read")
+	}
+
+	for _, sub := range []string{"dir0", "dir1", "dir2"} {
+		files, err := p.read(filepath.Join(dir, sub), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 4 {
+			t.Errorf("got %d entries in %s, want 4", len(files), sub)
+		}
+	}
+
+	p.mu.Lock()
+	_, deep := p.reads[filepath.Join(dir, "dir1", "dir0")]
+	p.mu.Unlock()
+
+	if deep {
+		t.Error("got a directory beyond the depth limit read")
+	}
+
+	// Once the walk leaves the root, everything below it is dropped.
+	p.release(dir)
+	if len(p.reads) != 0 {
+		t.Errorf("got %d reads kept after release, want none", len(p.reads))
+	}
+}
+
+func benchmarkTree(b *testing.B, opts ...Option) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := makeSyntheticTree(dir, 4, 6, 10); err != nil {
+		b.Fatal(err)
+	}
+
+	opts = append([]Option{WithColor(false), WithWriter(ioutil.Discard)}, opts...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Tree(dir, opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTreeSequential(b *testing.B) {
+	benchmarkTree(b)
+}
+
+func BenchmarkTreeParallel(b *testing.B) {
+	benchmarkTree(b, WithParallel(8))
+}
+
+func BenchmarkTreeSequentialStatus(b *testing.B) {
+	benchmarkTree(b, WithPermission(true), WithUID(true), WithGID(true), WithSize(true))
+}
+
+func BenchmarkTreeParallelStatus(b *testing.B) {
+	benchmarkTree(b, WithPermission(true), WithUID(true), WithGID(true), WithSize(true), WithParallel(8))
+}
+
+// slowFS makes every directory read take latency longer, as on a network
+// file system or with a cold cache, and returns a function undoing it.
+func slowFS(latency time.Duration) func() {
+	read := readDirRaw
+	readDirRaw = func(dir string) ([]os.FileInfo, error) {
+		time.Sleep(latency)
+		return read(dir)
+	}
+
+	return func() {
+		readDirRaw = read
+	}
+}
+
+func BenchmarkTreeSequentialSlowFS(b *testing.B) {
+	defer slowFS(time.Millisecond)()
+
+	benchmarkTree(b)
+}
+
+func BenchmarkTreeParallelSlowFS(b *testing.B) {
+	defer slowFS(time.Millisecond)()
+
+	benchmarkTree(b, WithParallel(8))
+}
@@ -0,0 +1,189 @@
+package tree
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// recordHeader names the columns of the CSV output.
+var recordHeader = []string{"path", "depth", "type", "mode", "uid", "gid", "size", "mtime", "target", "error", "exceeded"}
+
+// record is the flat description of an entry written by the NDJSON and CSV
+// renderers. The metadata fields are nil for the entries of a path list,
+// which have none. Error and Exceeded tell directories which could not be
+// read or were not opened because of the file limit from empty ones.
+type record struct {
+	Path     string     `json:"path"`
+	Depth    uint       `json:"depth"`
+	Type     string     `json:"type"`
+	Mode     *uint32    `json:"mode,omitempty"`
+	UID      *uint32    `json:"uid,omitempty"`
+	GID      *uint32    `json:"gid,omitempty"`
+	Size     *int64     `json:"size,omitempty"`
+	ModTime  *time.Time `json:"mtime,omitempty"`
+	Target   string     `json:"target,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Exceeded int        `json:"exceeded,omitempty"`
+}
+
+func newRecord(row *Row) record {
+	r := record{
+		Path:     row.path,
+		Depth:    row.level,
+		Type:     fileType(row.mode),
+		Target:   row.linkTarget,
+		Exceeded: row.exceeded,
+	}
+
+	if row.err != nil {
+		r.Error = row.err.Error()
+	}
+
+	if !row.listed() {
+		mode, uid, gid, size, mtime := unixPerm(row.mode), row.userID(), row.groupID(), row.byteSize(), row.modTime()
+		r.Mode, r.UID, r.GID, r.Size, r.ModTime = &mode, &uid, &gid, &size, &mtime
+	}
+
+	if row.usage != nil {
+		size := row.usage.Size
+		r.Size = &size
+	}
+
+	return r
+}
+
+// fields returns the columns of r in the order of recordHeader. Unknown
+// metadata is left empty.
+func (r record) fields() []string {
+	fields := []string{r.Path, fmt.Sprint(r.Depth), r.Type, "", "", "", "", "", r.Target, r.Error, ""}
+
+	if r.Mode != nil {
+		fields[3] = fmt.Sprintf("%04o", *r.Mode)
+	}
+	if r.UID != nil {
+		fields[4] = fmt.Sprint(*r.UID)
+	}
+	if r.GID != nil {
+		fields[5] = fmt.Sprint(*r.GID)
+	}
+	if r.Size != nil {
+		fields[6] = fmt.Sprint(*r.Size)
+	}
+	if r.ModTime != nil {
+		fields[7] = r.ModTime.Format(time.RFC3339Nano)
+	}
+	if r.Exceeded > 0 {
+		fields[10] = fmt.Sprint(r.Exceeded)
+	}
+
+	return fields
+}
+
+// ndjsonRenderer writes a JSON object per line for every entry, as the walk
+// reaches it.
+type ndjsonRenderer struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONRenderer returns a renderer which writes every entry to out as a
+// line of newline delimited JSON. Roots which do not exist are left out.
+func NewNDJSONRenderer(out io.Writer) Renderer {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	return &ndjsonRenderer{enc: enc}
+}
+
+func (n *ndjsonRenderer) BeginRoot(root Row) error {
+	if root.name == "" {
+		return nil
+	}
+
+	return n.enc.Encode(newRecord(&root))
+}
+
+func (n *ndjsonRenderer) EnterDir(row Row) error {
+	return nil
+}
+
+func (n *ndjsonRenderer) Entry(row Row) error {
+	return n.enc.Encode(newRecord(&row))
+}
+
+func (n *ndjsonRenderer) LeaveDir(row Row) error {
+	return nil
+}
+
+func (n *ndjsonRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (n *ndjsonRenderer) Finish(result Result) error {
+	return nil
+}
+
+// csvRenderer writes a header line and a CSV record for every entry, as the
+// walk reaches it.
+type csvRenderer struct {
+	w       *csv.Writer
+	started bool
+}
+
+// NewCSVRenderer returns a renderer which writes every entry to out as a CSV
+// record, after a header naming the columns. Roots which do not exist are
+// left out.
+func NewCSVRenderer(out io.Writer) Renderer {
+	return &csvRenderer{w: csv.NewWriter(out)}
+}
+
+// write writes the fields of a record and flushes them, so that the output
+// follows the walk.
+func (c *csvRenderer) write(fields []string) error {
+	if !c.started {
+		c.started = true
+		if err := c.w.Write(recordHeader); err != nil {
+			return err
+		}
+	}
+
+	if fields != nil {
+		if err := c.w.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRenderer) BeginRoot(root Row) error {
+	if root.name == "" {
+		return nil
+	}
+
+	return c.write(newRecord(&root).fields())
+}
+
+func (c *csvRenderer) EnterDir(row Row) error {
+	return nil
+}
+
+func (c *csvRenderer) Entry(row Row) error {
+	return c.write(newRecord(&row).fields())
+}
+
+func (c *csvRenderer) LeaveDir(row Row) error {
+	return nil
+}
+
+func (c *csvRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (c *csvRenderer) Finish(result Result) error {
+	// The header is written even if there are no records.
+	return c.write(nil)
+}
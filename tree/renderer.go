@@ -0,0 +1,146 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+)
+
+// Result holds the totals reported once the walk is finished.
+type Result struct {
+	Directories int
+	Files       int
+	// Errors is the number of directories which could not be read.
+	Errors int
+	// Skipped is the number of entries which were not listed because of the
+	// directories only mode or the file limit.
+	Skipped int
+	// Usage is the total disk usage of the root. It is only computed in du mode.
+	Usage *Usage
+	// SizeFormat is the format sizes are printed in.
+	SizeFormat SizeFormat
+	// NoReport tells the renderer to leave the totals out of the output.
+	NoReport bool
+}
+
+// String returns the summary line printed after the tree.
+func (r Result) String() string {
+	summary := fmt.Sprintf("%d directories, %d files", r.Directories, r.Files)
+
+	if r.Usage != nil {
+		summary = fmt.Sprintf("%s (%s on disk) used in %s", FormatSize(r.Usage.Size, r.SizeFormat), FormatSize(r.Usage.Allocated, r.SizeFormat), summary)
+	}
+
+	if r.Errors > 0 {
+		summary += fmt.Sprintf(", %d errors", r.Errors)
+	}
+
+	if r.Skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", r.Skipped)
+	}
+
+	return summary
+}
+
+// OutputFormat selects the built-in renderer.
+type OutputFormat int
+
+const (
+	// OutputText prints the tree with box-drawing connectors.
+	OutputText OutputFormat = iota
+	// OutputJSON prints a JSON document.
+	OutputJSON
+	// OutputXML prints an XML document in the format of tree -X.
+	OutputXML
+	// OutputDOT prints a Graphviz DOT graph.
+	OutputDOT
+	// OutputMermaid prints a Mermaid flowchart.
+	OutputMermaid
+	// OutputMarkdown prints the text tree in a fenced Markdown code block.
+	OutputMarkdown
+	// OutputMarkdownList prints a nested Markdown list.
+	OutputMarkdownList
+	// OutputNDJSON prints a line of JSON per entry as the walk proceeds.
+	OutputNDJSON
+	// OutputCSV prints a CSV record per entry as the walk proceeds.
+	OutputCSV
+	// OutputHTML prints an HTML page with collapsible directories.
+	OutputHTML
+)
+
+var outputFormatNames = map[string]OutputFormat{
+	"text":          OutputText,
+	"json":          OutputJSON,
+	"xml":           OutputXML,
+	"dot":           OutputDOT,
+	"mermaid":       OutputMermaid,
+	"markdown":      OutputMarkdown,
+	"markdown-list": OutputMarkdownList,
+	"ndjson":        OutputNDJSON,
+	"csv":           OutputCSV,
+	"html":          OutputHTML,
+}
+
+// ParseOutputFormat returns the OutputFormat called name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	if f, ok := outputFormatNames[name]; ok {
+		return f, nil
+	}
+
+	return OutputText, fmt.Errorf("unknown format %q: must be one of text, json, xml, dot, mermaid, markdown, markdown-list, ndjson, csv, html", name)
+}
+
+// Renderer receives the walked hierarchy in tree order.
+// BeginRoot and EndRoot surround the entries of each root; the row of a
+// root has level 0 and no FileInfo if the root does not exist. Rows of a
+// path list have no FileInfo either. Entry is
+// called for every listed entry. EnterDir and LeaveDir surround the entries
+// of a directory that is descended into.
+type Renderer interface {
+	BeginRoot(root Row) error
+	EnterDir(row Row) error
+	Entry(row Row) error
+	LeaveDir(row Row) error
+	EndRoot(root Row) error
+	Finish(result Result) error
+}
+
+// textRenderer prints the tree with box-drawing connectors.
+type textRenderer struct {
+	out io.Writer
+}
+
+// NewTextRenderer returns the default renderer, which writes box-drawing text to out.
+func NewTextRenderer(out io.Writer) Renderer {
+	return &textRenderer{out: out}
+}
+
+func (t *textRenderer) BeginRoot(root Row) error {
+	_, err := fmt.Fprintln(t.out, root.Root())
+	return err
+}
+
+func (t *textRenderer) EnterDir(row Row) error {
+	return nil
+}
+
+func (t *textRenderer) Entry(row Row) error {
+	_, err := fmt.Fprintln(t.out, row.Str())
+	return err
+}
+
+func (t *textRenderer) LeaveDir(row Row) error {
+	return nil
+}
+
+func (t *textRenderer) EndRoot(root Row) error {
+	return nil
+}
+
+func (t *textRenderer) Finish(result Result) error {
+	if result.NoReport {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(t.out, "\n%s\n", result)
+	return err
+}
@@ -0,0 +1,831 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func contains(sl []string, s string) bool {
+	for _, v := range sl {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ext(fileName string) string {
+	ext := filepath.Ext(fileName)
+
+	if ext == "" {
+		return ext
+	} else {
+		return ext[1:]
+	}
+}
+
+// Row is a single entry of the tree, as passed to a Renderer.
+type Row struct {
+	fileInfo     os.FileInfo
+	name         string
+	mode         os.FileMode
+	dir          bool
+	path         string
+	level        uint
+	onRightAngle bool
+	isBlank      []bool
+	colored      bool
+	theme        *Theme
+	permission   bool
+	uid          bool
+	gid          bool
+	size         bool
+	datetime     bool
+	git          bool
+	gitStatus    GitStatus
+	fullPath     bool
+	absolute     bool
+	noIndent     bool
+	usage        *Usage
+	sizeFormat   SizeFormat
+	linkTarget   string
+	brokenLink   bool
+	recursive    bool
+	exceeded     int
+	changed      bool
+	err          error
+}
+
+// FileInfo returns the file information of the entry. It is nil if the root
+// does not exist and for the entries of a path list, which are not read from
+// the file system.
+func (row *Row) FileInfo() os.FileInfo {
+	return row.fileInfo
+}
+
+// setFile fills in the name and type of the entry from fi.
+func (row *Row) setFile(fi os.FileInfo) {
+	row.name = fi.Name()
+	row.mode = fi.Mode()
+	row.dir = fi.IsDir()
+
+	if _, listed := fi.(listedFile); !listed {
+		row.fileInfo = fi
+	}
+}
+
+// listed reports whether the row is an entry of a path list, of which only
+// the name and whether it is a directory are known.
+func (row *Row) listed() bool {
+	return row.fileInfo == nil
+}
+
+// byteSize returns the size of the entry, or 0 without file information.
+func (row *Row) byteSize() int64 {
+	if row.fileInfo == nil {
+		return 0
+	}
+
+	return row.fileInfo.Size()
+}
+
+// modTime returns the modification time of the entry, or the zero time
+// without file information.
+func (row *Row) modTime() time.Time {
+	if row.fileInfo == nil {
+		return time.Time{}
+	}
+
+	return row.fileInfo.ModTime()
+}
+
+// Level returns the depth of the entry. Entries directly under the root are at level 1.
+func (row *Row) Level() uint {
+	return row.level
+}
+
+// IsLast reports whether the entry is the last one of its directory.
+func (row *Row) IsLast() bool {
+	return row.onRightAngle
+}
+
+// Usage returns the disk usage of the entry, accumulated for directories.
+// It is nil unless the walk runs in du mode.
+func (row *Row) Usage() *Usage {
+	return row.usage
+}
+
+func (row *Row) Status() string {
+	status := ""
+
+	if row.permission {
+		status += row.Mode() + " "
+	}
+
+	if row.uid {
+		status += row.User() + " "
+	}
+
+	if row.gid {
+		status += row.Group() + " "
+	}
+
+	if row.size {
+		status += row.Size() + " "
+	}
+
+	if row.usage != nil {
+		status += row.Allocated() + " "
+	}
+
+	if row.datetime {
+		status += row.Datetime() + " "
+	}
+
+	if row.git {
+		status += row.Git() + " "
+	}
+
+	if status != "" {
+		return fmt.Sprintf("[%s]  ", strings.TrimSpace(status))
+	}
+
+	return status
+}
+
+func (row *Row) Datetime() string {
+	mt := row.modTime().Format("2006-01-02 15:04")
+
+	if row.colored {
+		mt = row.theme.paint(ThemeDate, mt, colorBlue)
+	}
+
+	return mt
+}
+
+// GitStatus returns the git status of the entry, and false if the walk does
+// not show git status or the entry is outside a repository.
+func (row *Row) GitStatus() (GitStatus, bool) {
+	return row.gitStatus, row.git
+}
+
+func (row *Row) Git() string {
+	code := row.gitStatus.String()
+
+	if row.colored {
+		code = colorGitState(row.gitStatus.Staged) + colorGitState(row.gitStatus.Unstaged)
+	}
+
+	return code
+}
+
+func colorGitState(s GitState) string {
+	c := string(s)
+
+	switch s {
+	case GitNew:
+		return colorLightGreen(c)
+	case GitModified:
+		return colorLightBlue(c)
+	case GitDeleted, GitConflicted:
+		return colorLightRed(c)
+	}
+
+	return colorDarkGray(c)
+}
+
+func (row *Row) Size() string {
+	size := row.byteSize()
+
+	if row.usage != nil {
+		size = row.usage.Size
+	} else if row.isDir() {
+		return "-"
+	}
+
+	fs := FormatSize(size, row.sizeFormat)
+
+	if row.colored {
+		fs = row.theme.paint(ThemeSize, fs, colorGreen)
+	}
+
+	return fs
+}
+
+// Allocated returns the space allocated on disk for the entry, accumulated
+// for directories. It is only known in du mode.
+func (row *Row) Allocated() string {
+	if row.usage == nil {
+		return "-"
+	}
+
+	fs := FormatSize(row.usage.Allocated, row.sizeFormat) + " on disk"
+
+	if row.colored {
+		fs = row.theme.paint(ThemeSize, fs, colorGreen)
+	}
+
+	return fs
+}
+
+func (row *Row) User() string {
+	userName := row.userName()
+
+	if row.colored {
+		userName = row.theme.paint(ThemeUser, userName, colorYellow)
+	}
+
+	return userName
+}
+
+func (row *Row) Group() string {
+	group := row.groupName()
+
+	if row.colored {
+		group = row.theme.paint(ThemeGroup, group, colorYellow)
+	}
+
+	return group
+}
+
+func (row *Row) Name() string {
+	name := row.displayName()
+
+	if row.isLink() {
+		return row.linkName()
+	}
+
+	if row.colored {
+		if c := row.theme.nameColor(row.name, row.mode, false); c != "" {
+			name = sgr(c, name)
+			if row.isDir() {
+				return name + "/"
+			}
+
+			if row.isExec() {
+				return name + "*"
+			}
+
+			return name
+		}
+
+		switch row.Category() {
+		case CategoryDir:
+			return colorLightBlue(name) + "/"
+		case CategoryExec:
+			return colorLightGreen(name) + "*"
+		case CategoryImmediate:
+			return formatUnderLine(colorLightYellow(name))
+		case CategoryImage:
+			return colorLightMagenta(name)
+		case CategoryVideo, CategoryMusic:
+			return colorPurple(name)
+		case CategoryCrypto:
+			return colorLightCyan(name)
+		case CategoryDocument:
+			return colorGreen(name)
+		case CategoryCompressed:
+			return colorRed(name)
+		case CategoryTemp:
+			return colorDarkGray(name)
+		case CategoryCompiled:
+			return colorYellow(name)
+		}
+	}
+
+	return name
+}
+
+// LinkTarget returns the target of a symbolic link, or "" for other entries.
+func (row *Row) LinkTarget() string {
+	return row.linkTarget
+}
+
+func (row *Row) linkName() string {
+	name := row.displayName()
+	target := row.linkTarget
+
+	if row.colored {
+		c := row.theme.nameColor(row.name, row.mode, row.brokenLink)
+		if c == "target" {
+			c = ""
+			if fi, err := os.Stat(row.path); err == nil {
+				c = row.theme.nameColor(filepath.Base(row.linkTarget), fi.Mode(), false)
+			}
+		}
+
+		switch {
+		case c != "" && row.brokenLink:
+			name = sgr(c, name)
+			target = row.theme.paint("mi", target, func(s string) string { return sgr(c, s) })
+		case c != "":
+			name = sgr(c, name)
+		case row.brokenLink:
+			name = colorLightRed(name)
+			target = colorLightRed(target)
+		default:
+			name = colorCyan(name)
+		}
+	}
+
+	name += " -> " + target
+
+	if row.recursive {
+		name += "  " + row.marker("recursive, not followed")
+	}
+
+	return name
+}
+
+// Path returns the path of the entry: the root as given joined with the
+// names of the directories leading to the entry.
+func (row *Row) Path() string {
+	return row.path
+}
+
+// displayName returns the name the entry is printed with, which is its path
+// in full path mode.
+func (row *Row) displayName() string {
+	if row.absolute {
+		if abs, err := filepath.Abs(row.path); err == nil {
+			return abs
+		}
+		return row.path
+	}
+
+	if row.fullPath {
+		return row.path
+	}
+
+	return row.name
+}
+
+// Root returns the path of a root as given, with the error marker if it could not be read.
+func (row *Row) Root() string {
+	root := row.path
+	if row.absolute {
+		root = row.displayName()
+	}
+
+	if row.err != nil {
+		root += "  " + row.errorMarker()
+	}
+
+	return root
+}
+
+// marker returns a note such as [error opening dir] printed after the name,
+// colored like the other markers.
+func (row *Row) marker(format string, a ...interface{}) string {
+	marker := "[" + fmt.Sprintf(format, a...) + "]"
+	if row.colored {
+		marker = colorLightRed(marker)
+	}
+
+	return marker
+}
+
+func (row *Row) errorMarker() string {
+	return row.marker("error opening dir")
+}
+
+// Err returns the error which prevented reading the directory, if any.
+func (row *Row) Err() error {
+	return row.err
+}
+
+// Exceeded returns the number of entries of a directory which was not
+// descended into because it has more entries than the file limit, or 0.
+func (row *Row) Exceeded() int {
+	return row.exceeded
+}
+
+// Changed reports whether the entry changed recently in watch mode.
+func (row *Row) Changed() bool {
+	return row.changed
+}
+
+func (row *Row) File() string {
+	name := row.Name()
+	if row.changed {
+		if row.colored {
+			name = formatReverse(name)
+		} else {
+			name += "  [changed]"
+		}
+	}
+
+	file := fmt.Sprintf("%s%s", row.Status(), name)
+
+	if row.err != nil {
+		file += "  " + row.errorMarker()
+	}
+
+	if row.exceeded > 0 {
+		file += "  " + row.marker("%d entries exceeds filelimit", row.exceeded)
+	}
+
+	return file
+}
+
+func (row *Row) Str() string {
+	if row.noIndent {
+		return row.File()
+	}
+
+	var str string
+	for i := 0; i < int(row.level-1); i++ {
+		if row.isBlank[i] {
+			str += row.connectorBlank()
+		} else {
+			str += row.connectorLine()
+		}
+	}
+
+	if row.onRightAngle {
+		str += row.connectorRightAngle() + row.File()
+	} else {
+		str += row.connectorCross() + row.File()
+	}
+
+	return str
+}
+
+func (row *Row) Mode() string {
+	var m uint32
+	m = uint32(row.mode)
+	const str = "dalTLDpSugct?"
+	var modeStr [10]string
+
+	for i, c := range str {
+		if m&(1<<uint(32-1-i)) != 0 {
+			if row.colored {
+				modeStr[0] = row.theme.paint(ThemePermType, string(c), colorLightBlue)
+			} else {
+				modeStr[0] = string(c)
+			}
+		}
+	}
+
+	if modeStr[0] == "" {
+		modeStr[0] = "."
+	}
+
+	w := 1
+	const rwx = "rwxrwxrwx"
+	for i, c := range rwx {
+		if m&(1<<uint(9-1-i)) != 0 {
+			if row.colored {
+				switch s := string(c); s {
+				case "r":
+					modeStr[w] = row.theme.paint(ThemePermRead, string(c), colorYellow)
+				case "w":
+					modeStr[w] = row.theme.paint(ThemePermWrite, string(c), colorRed)
+				case "x":
+					modeStr[w] = row.theme.paint(ThemePermExec, string(c), colorGreen)
+				}
+			} else {
+				modeStr[w] = string(c)
+			}
+		} else {
+			modeStr[w] = "-"
+		}
+		w++
+	}
+
+	return strings.Join(modeStr[:], "")
+}
+
+func (row *Row) connectorCross() string {
+	if row.colored {
+		return row.theme.paint(ThemeConnector, crossConnector, colorDarkGray)
+	}
+
+	return crossConnector
+}
+
+func (row *Row) connectorLine() string {
+	if row.colored {
+		return row.theme.paint(ThemeConnector, lineConnector, colorDarkGray)
+	}
+
+	return lineConnector
+}
+
+func (row *Row) connectorRightAngle() string {
+	if row.colored {
+		return row.theme.paint(ThemeConnector, rightAngleConnector, colorDarkGray)
+	}
+
+	return rightAngleConnector
+}
+
+func (row *Row) connectorBlank() string {
+	return blankConnector
+}
+
+func (row *Row) userID() uint32 {
+	if row.fileInfo != nil {
+		if stat, ok := row.fileInfo.Sys().(*syscall.Stat_t); ok {
+			return stat.Uid
+		}
+	}
+
+	return uint32(os.Getuid())
+}
+
+func (row *Row) groupID() uint32 {
+	if row.fileInfo != nil {
+		if stat, ok := row.fileInfo.Sys().(*syscall.Stat_t); ok {
+			return stat.Gid
+		}
+	}
+
+	return uint32(os.Getgid())
+}
+
+func (row *Row) userName() string {
+	return lookupUserName(row.userID())
+}
+
+func (row *Row) groupName() string {
+	return lookupGroupName(row.groupID())
+}
+
+// Names of users and groups are cached, as every row of a tree usually
+// shares a handful of them.
+var (
+	namesMu    sync.Mutex
+	userNames  = map[uint32]string{}
+	groupNames = map[uint32]string{}
+)
+
+// lookupUserName returns the name of the user uid, or uid itself if unknown.
+func lookupUserName(uid uint32) string {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("%d", uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+
+	return name
+}
+
+// lookupGroupName returns the name of the group gid, or gid itself if unknown.
+func lookupGroupName(gid uint32) string {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("%d", gid)
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	groupNames[gid] = name
+
+	return name
+}
+
+// Category is the class of an entry which decides the color of its name.
+type Category string
+
+const (
+	CategoryNone       Category = ""
+	CategoryLink       Category = "link"
+	CategoryBrokenLink Category = "broken-link"
+	CategoryDir        Category = "dir"
+	CategoryExec       Category = "exec"
+	CategoryImmediate  Category = "immediate"
+	CategoryImage      Category = "image"
+	CategoryVideo      Category = "video"
+	CategoryMusic      Category = "music"
+	CategoryCrypto     Category = "crypto"
+	CategoryDocument   Category = "document"
+	CategoryCompressed Category = "compressed"
+	CategoryTemp       Category = "temp"
+	CategoryCompiled   Category = "compiled"
+)
+
+// Category returns the class of the entry. The first matching class wins,
+// in the order of the constants.
+func (row *Row) Category() Category {
+	if row.name == "" {
+		return CategoryNone
+	}
+
+	switch {
+	case row.isLink() && row.brokenLink:
+		return CategoryBrokenLink
+	case row.isLink():
+		return CategoryLink
+	case row.isDir():
+		return CategoryDir
+	case row.isExec():
+		return CategoryExec
+	case row.isImmediate():
+		return CategoryImmediate
+	case row.isImage():
+		return CategoryImage
+	case row.isVideo():
+		return CategoryVideo
+	case row.isMusic():
+		return CategoryMusic
+	case row.isCrypto():
+		return CategoryCrypto
+	case row.isDocument():
+		return CategoryDocument
+	case row.isCompressed():
+		return CategoryCompressed
+	case row.isTemp():
+		return CategoryTemp
+	case row.isCompiled():
+		return CategoryCompiled
+	}
+
+	return CategoryNone
+}
+
+func (row *Row) isLink() bool {
+	return row.mode&os.ModeSymlink != 0
+}
+
+func (row *Row) isDir() bool {
+	return row.dir
+}
+
+func (row *Row) isExec() bool {
+	var m uint32
+	m = uint32(row.mode)
+
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if m&(1<<uint(9-1-i)) != 0 && i%3 == 2 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (row *Row) isImmediate() bool {
+	name := row.name
+
+	nameWithoutExt := name[:len(name)-len(filepath.Ext(name))]
+	if strings.ToLower(nameWithoutExt) == "readme" {
+		return true
+	}
+
+	immediateFiles := []string{
+		"Makefile", "Cargo.toml", "SConstruct", "CMakeLists.txt",
+		"build.gradle", "pom.xml", "Rakefile", "package.json", "Gruntfile.js",
+		"Gruntfile.coffee", "BUILD", "BUILD.bazel", "WORKSPACE", "build.xml",
+		"webpack.config.js", "meson.build",
+	}
+
+	if contains(immediateFiles, name) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isImage() bool {
+	imageExts := []string{
+		"png", "jpeg", "jpg", "gif", "bmp", "tiff", "tif",
+		"ppm", "pgm", "pbm", "pnm", "webp", "raw", "arw",
+		"svg", "stl", "eps", "dvi", "ps", "cbr", "jpf",
+		"cbz", "xpm", "ico", "cr2", "orf", "nef",
+	}
+
+	ext := ext(row.name)
+
+	if contains(imageExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isVideo() bool {
+	videoExts := []string{
+		"avi", "flv", "m2v", "m4v", "mkv", "mov", "mp4", "mpeg",
+		"mpg", "ogm", "ogv", "vob", "wmv", "webm", "m2ts",
+	}
+
+	ext := ext(row.name)
+
+	if contains(videoExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isMusic() bool {
+	musicExts := []string{
+		"aac", "m4a", "mp3", "ogg", "wma", "mka", "opus",
+		"alac", "ape", "flac", "wav",
+	}
+
+	ext := ext(row.name)
+
+	if contains(musicExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isCrypto() bool {
+	cryptoExts := []string{
+		"asc", "enc", "gpg", "pgp", "sig", "signature", "pfx", "p12",
+	}
+
+	ext := ext(row.name)
+
+	if contains(cryptoExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isDocument() bool {
+	documentExts := []string{
+		"djvu", "doc", "docx", "dvi", "eml", "eps", "fotd",
+		"odp", "odt", "pdf", "ppt", "pptx", "rtf",
+		"xls", "xlsx",
+	}
+
+	ext := ext(row.name)
+
+	if contains(documentExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isCompressed() bool {
+	compressedExts := []string{
+		"zip", "tar", "Z", "z", "gz", "bz2", "a", "ar", "7z",
+		"iso", "dmg", "tc", "rar", "par", "tgz", "xz", "txz",
+		"lz", "tlz", "lzma", "deb", "rpm", "zst",
+	}
+
+	ext := ext(row.name)
+
+	if contains(compressedExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isTemp() bool {
+	name := row.name
+
+	// XXXX~ or #XXXX#
+	if name[len(name)-1:] == "~" || (name[:1] == "#" && name[len(name)-1:] == "#") {
+		return true
+	}
+
+	tempExts := []string{"tmp", "swp", "swo", "swn", "bak", "bk"}
+
+	ext := ext(name)
+
+	if contains(tempExts, ext) {
+		return true
+	}
+
+	return false
+}
+
+func (row *Row) isCompiled() bool {
+	compiledExts := []string{"class", "elc", "hi", "o", "pyc", "zwc"}
+
+	ext := ext(row.name)
+
+	if contains(compiledExts, ext) {
+		return true
+	}
+
+	return false
+}
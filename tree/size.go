@@ -0,0 +1,54 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SizeFormat is the format sizes are printed in.
+type SizeFormat int
+
+const (
+	// SizeSI prints sizes in powers of 1000 with SI prefixes, e.g. 1.5k.
+	SizeSI SizeFormat = iota
+	// SizeIEC prints sizes in powers of 1024 with IEC units, e.g. 1.5KiB.
+	SizeIEC
+	// SizeBytes prints sizes in exact bytes.
+	SizeBytes
+)
+
+var (
+	iecUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = []string{"k", "M", "G", "T", "P", "E"}
+)
+
+// FormatSize returns size in a human readable form with one decimal.
+// Sizes below one unit are printed in bytes.
+func FormatSize(size int64, format SizeFormat) string {
+	base, units := 1000.0, siUnits
+	switch format {
+	case SizeBytes:
+		return strconv.FormatInt(size, 10)
+	case SizeIEC:
+		base, units = 1024.0, iecUnits
+	}
+
+	if size < int64(base) {
+		return strconv.FormatInt(size, 10)
+	}
+
+	v := float64(size)
+	i := -1
+	for v >= base && i < len(units)-1 {
+		v /= base
+		i++
+	}
+
+	// Rounding may carry over to the next unit, e.g. 1023.96KiB.
+	if fmt.Sprintf("%.1f", v) == fmt.Sprintf("%.1f", base) && i < len(units)-1 {
+		v /= base
+		i++
+	}
+
+	return fmt.Sprintf("%.1f%s", v, units[i])
+}
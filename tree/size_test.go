@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size   int64
+		format SizeFormat
+		want   string
+	}{
+		{0, SizeIEC, "0"},
+		{1023, SizeIEC, "1023"},
+		{1024, SizeIEC, "1.0KiB"},
+		{1536, SizeIEC, "1.5KiB"},
+		{5000000, SizeIEC, "4.8MiB"},
+		{1048575, SizeIEC, "1.0MiB"},
+		{1 << 40, SizeIEC, "1.0TiB"},
+		{math.MaxInt64, SizeIEC, "8.0EiB"},
+		{999, SizeSI, "999"},
+		{1000, SizeSI, "1.0k"},
+		{5000000, SizeSI, "5.0M"},
+		{999999, SizeSI, "1.0M"},
+		{2500000000000000000, SizeSI, "2.5E"},
+		{5000000, SizeBytes, "5000000"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.size, tt.format); got != tt.want {
+			t.Errorf("FormatSize(%d, %d) = %q, want %q", tt.size, tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SortOrder is the order of the entries within a directory.
+type SortOrder int
+
+const (
+	// SortName sorts by name.
+	SortName SortOrder = iota
+	// SortVersion sorts by name, comparing runs of digits numerically.
+	SortVersion
+	// SortSize sorts by size, largest first.
+	SortSize
+	// SortModTime sorts by modification time, newest first.
+	SortModTime
+	// SortChangeTime sorts by status change time, newest first.
+	SortChangeTime
+	// SortExtension sorts by extension, then by name.
+	SortExtension
+	// SortNone keeps the order in which the directory is read.
+	SortNone
+)
+
+var sortOrderNames = map[string]SortOrder{
+	"name":    SortName,
+	"version": SortVersion,
+	"size":    SortSize,
+	"mtime":   SortModTime,
+	"ctime":   SortChangeTime,
+	"ext":     SortExtension,
+	"none":    SortNone,
+}
+
+// ParseSortOrder returns the SortOrder called name.
+func ParseSortOrder(name string) (SortOrder, error) {
+	if s, ok := sortOrderNames[name]; ok {
+		return s, nil
+	}
+
+	return SortName, fmt.Errorf("unknown sort order %q: must be one of name, version, size, mtime, ctime, ext, none", name)
+}
+
+// sortFiles sorts files in place according to the walker's options.
+func (w *walker) sortFiles(files []os.FileInfo) {
+	if w.sortOrder != SortNone {
+		less := lessFunc(w.sortOrder)
+		sort.SliceStable(files, func(i, j int) bool {
+			if w.reverse {
+				return less(files[j], files[i])
+			}
+
+			return less(files[i], files[j])
+		})
+	} else if w.reverse {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+
+	if w.dirsFirst || w.filesFirst {
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].IsDir() == w.dirsFirst && files[j].IsDir() != w.dirsFirst
+		})
+	}
+}
+
+func lessFunc(order SortOrder) func(a, b os.FileInfo) bool {
+	byName := func(a, b os.FileInfo) bool {
+		return a.Name() < b.Name()
+	}
+
+	switch order {
+	case SortVersion:
+		return func(a, b os.FileInfo) bool {
+			if c := compareVersion(a.Name(), b.Name()); c != 0 {
+				return c < 0
+			}
+
+			return byName(a, b)
+		}
+	case SortSize:
+		return func(a, b os.FileInfo) bool {
+			if a.Size() != b.Size() {
+				return a.Size() > b.Size()
+			}
+
+			return byName(a, b)
+		}
+	case SortModTime:
+		return func(a, b os.FileInfo) bool {
+			if !a.ModTime().Equal(b.ModTime()) {
+				return a.ModTime().After(b.ModTime())
+			}
+
+			return byName(a, b)
+		}
+	case SortChangeTime:
+		return func(a, b os.FileInfo) bool {
+			ca, cb := changeTime(a), changeTime(b)
+			if !ca.Equal(cb) {
+				return ca.After(cb)
+			}
+
+			return byName(a, b)
+		}
+	case SortExtension:
+		return func(a, b os.FileInfo) bool {
+			ea, eb := ext(a.Name()), ext(b.Name())
+			if ea != eb {
+				return ea < eb
+			}
+
+			return byName(a, b)
+		}
+	}
+
+	return byName
+}
+
+// compareVersion compares a and b like strings, except that runs of digits
+// are compared by their numeric value, so that "file2" comes before "file10".
+func compareVersion(a, b string) int {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+
+		if da && db {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return compareInt(len(ta), len(tb))
+			}
+
+			if ta != tb {
+				return strings.Compare(ta, tb)
+			}
+
+			// Equal values: fewer leading zeros first.
+			if len(na) != len(nb) {
+				return compareInt(len(na), len(nb))
+			}
+
+			a, b = ra, rb
+			continue
+		}
+
+		if a[0] != b[0] {
+			return compareInt(int(a[0]), int(b[0]))
+		}
+
+		a, b = a[1:], b[1:]
+	}
+
+	return compareInt(len(a), len(b))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,27 @@
+package tree
+
+import "testing"
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file10", "file10", 0},
+		{"v1.9.0", "v1.10.0", -1},
+		{"a", "b", -1},
+		{"a1", "a", 1},
+		{"file01", "file1", 1},
+		{"file001", "file2", -1},
+		{"2", "a", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
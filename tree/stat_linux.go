@@ -0,0 +1,16 @@
+package tree
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the status change time of fi, or its modification time if unknown.
+func changeTime(fi os.FileInfo) time.Time {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
+	}
+
+	return fi.ModTime()
+}
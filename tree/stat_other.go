@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package tree
+
+import (
+	"os"
+	"time"
+)
+
+// changeTime returns the modification time of fi, as the status change time is not available.
+func changeTime(fi os.FileInfo) time.Time {
+	return fi.ModTime()
+}
@@ -0,0 +1,240 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Theme keys for the parts of a row which are not file names.
+const (
+	ThemeConnector = "connector"
+	ThemeSize      = "size"
+	ThemeUser      = "user"
+	ThemeGroup     = "group"
+	ThemeDate      = "date"
+	ThemePermType  = "perm-type"
+	ThemePermRead  = "perm-read"
+	ThemePermWrite = "perm-write"
+	ThemePermExec  = "perm-exec"
+)
+
+var themeKeys = []string{
+	ThemeConnector, ThemeSize, ThemeUser, ThemeGroup, ThemeDate,
+	ThemePermType, ThemePermRead, ThemePermWrite, ThemePermExec,
+}
+
+// lsColorsKeys are the file type keys of LS_COLORS which are used for names.
+var lsColorsKeys = []string{
+	"no", "fi", "di", "ln", "or", "mi", "pi", "so", "do", "bd", "cd",
+	"su", "sg", "tw", "ow", "st", "ex",
+}
+
+type suffixColor struct {
+	suffix string
+	color  string
+}
+
+// Theme holds colors as SGR parameters, e.g. "01;34", overriding the
+// built-in scheme. Anything the theme has no color for is printed with the
+// built-in color. A nil *Theme is the built-in scheme.
+type Theme struct {
+	colors   map[string]string
+	suffixes []suffixColor
+}
+
+// NewTheme returns an empty theme.
+func NewTheme() *Theme {
+	return &Theme{colors: map[string]string{}}
+}
+
+// ParseLSColors adds the file type and suffix colors of an LS_COLORS value.
+// Entries which are malformed or not used by gotree are skipped.
+func (t *Theme) ParseLSColors(s string) {
+	for _, entry := range strings.Split(s, ":") {
+		eq := strings.Index(entry, "=")
+		if eq < 0 {
+			continue
+		}
+
+		key, color := entry[:eq], entry[eq+1:]
+		if strings.HasPrefix(key, "*") {
+			if len(key) > 1 && isSGR(color) {
+				t.suffixes = append(t.suffixes, suffixColor{key[1:], color})
+			}
+			continue
+		}
+
+		if !contains(lsColorsKeys, key) {
+			continue
+		}
+
+		if isSGR(color) || (key == "ln" && color == "target") {
+			t.colors[key] = color
+		}
+	}
+}
+
+// ReadFile adds the colors of a theme file. Each line holds a key and a color
+// separated by spaces or "=". Keys are the LS_COLORS file type keys, "*suffix"
+// patterns and the Theme* constants. Empty lines and lines starting with "#"
+// are skipped.
+func (t *Theme) ReadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == '=' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: want a key and a color, got %q", path, n, line)
+		}
+
+		key, color := fields[0], fields[1]
+		if !isSGR(color) && !(key == "ln" && color == "target") {
+			return fmt.Errorf("%s:%d: invalid color %q", path, n, color)
+		}
+
+		switch {
+		case strings.HasPrefix(key, "*") && len(key) > 1:
+			t.suffixes = append(t.suffixes, suffixColor{key[1:], color})
+		case contains(lsColorsKeys, key) || contains(themeKeys, key):
+			t.colors[key] = color
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q", path, n, key)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// isSGR reports whether s is a list of SGR parameters.
+func isSGR(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != ';' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// color returns the color of key, or "" if the theme has none.
+func (t *Theme) color(key string) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.colors[key]
+}
+
+// paint colors s with the color of key, or with fallback if the theme has none.
+func (t *Theme) paint(key string, s string, fallback func(string) string) string {
+	if c := t.color(key); c != "" {
+		return sgr(c, s)
+	}
+
+	return fallback(s)
+}
+
+// suffixColor returns the color of the last suffix pattern matching name.
+// Suffixes are matched case-insensitively like ls does.
+func (t *Theme) suffixColor(name string) string {
+	if t == nil {
+		return ""
+	}
+
+	lower := strings.ToLower(name)
+	for i := len(t.suffixes) - 1; i >= 0; i-- {
+		if strings.HasSuffix(lower, strings.ToLower(t.suffixes[i].suffix)) {
+			return t.suffixes[i].color
+		}
+	}
+
+	return ""
+}
+
+// nameColor returns the color of a file name following the precedence of
+// ls: special types and permission bits first, then suffixes for regular
+// files. It returns "" if the theme has no color for the file.
+func (t *Theme) nameColor(name string, mode os.FileMode, broken bool) string {
+	if t == nil {
+		return ""
+	}
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if c := t.colors[k]; c != "" {
+				return c
+			}
+		}
+		return ""
+	}
+
+	switch {
+	case mode&os.ModeSymlink != 0:
+		if broken {
+			return first("or", "ln")
+		}
+		return first("ln")
+	case mode.IsDir():
+		switch {
+		case mode&os.ModeSticky != 0 && mode&0002 != 0:
+			return first("tw", "ow", "st", "di")
+		case mode&0002 != 0:
+			return first("ow", "di")
+		case mode&os.ModeSticky != 0:
+			return first("st", "di")
+		}
+		return first("di")
+	case mode&os.ModeNamedPipe != 0:
+		return first("pi")
+	case mode&os.ModeSocket != 0:
+		return first("so")
+	case mode&os.ModeCharDevice != 0:
+		return first("cd")
+	case mode&os.ModeDevice != 0:
+		return first("bd")
+	}
+
+	var c string
+	switch {
+	case mode&os.ModeSetuid != 0:
+		c = first("su")
+	case mode&os.ModeSetgid != 0:
+		c = first("sg")
+	}
+
+	if c == "" && mode&0111 != 0 {
+		c = first("ex")
+	}
+
+	if c == "" {
+		c = t.suffixColor(name)
+	}
+
+	if c == "" {
+		c = first("fi")
+	}
+
+	return c
+}
+
+func sgr(params, s string) string {
+	return "\x1b[" + params + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,78 @@
+package tree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestThemeNameColor(t *testing.T) {
+	theme := NewTheme()
+	theme.ParseLSColors("rs=0:di=01;34:ln=01;36:or=40;31;01:su=37;41:tw=30;42:st=37;44:ex=01;32:*.tar=01;31:*.PNG=01;35:bogus:*.x=zz")
+
+	tests := []struct {
+		name   string
+		mode   os.FileMode
+		broken bool
+		want   string
+	}{
+		{"dir", os.ModeDir | 0755, false, "01;34"},
+		{"tmp", os.ModeDir | os.ModeSticky | 0777, false, "30;42"},
+		{"shared", os.ModeDir | os.ModeSticky | 0755, false, "37;44"},
+		{"link", os.ModeSymlink | 0777, false, "01;36"},
+		{"broken", os.ModeSymlink | 0777, true, "40;31;01"},
+		{"passwd", os.ModeSetuid | 0755, false, "37;41"},
+		{"run.tar", 0755, false, "01;32"},
+		{"a.tar", 0644, false, "01;31"},
+		{"a.png", 0644, false, "01;35"},
+		{"a.x", 0644, false, ""},
+		{"README", 0644, false, ""},
+	}
+
+	for _, tt := range tests {
+		if got := theme.nameColor(tt.name, tt.mode, tt.broken); got != tt.want {
+			t.Errorf("nameColor(%q, %v, %v) = %q, want %q", tt.name, tt.mode, tt.broken, got, tt.want)
+		}
+	}
+}
+
+func TestThemeReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "theme")
+	content := "# gotree theme\n\nconnector 31\nsize=01;32\n*.md 35\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	theme := NewTheme()
+	if err := theme.ReadFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	fi := mustLstat(t, TMP_DIR+"/01/README.md")
+	row := Row{level: 1, onRightAngle: true, colored: true, size: true, theme: theme}
+	row.setFile(fi)
+	got := row.Str()
+	want := "\x1b[31m└── \x1b[0m[\x1b[01;32m" + FormatSize(fi.Size(), SizeSI) + "\x1b[0m]  \x1b[35mREADME.md\x1b[0m"
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	for _, bad := range []string{"connector", "nope 31", "size red"} {
+		if err := ioutil.WriteFile(file, []byte(bad+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := NewTheme().ReadFile(file); err == nil {
+			t.Errorf("ReadFile(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+// Package tree walks a directory hierarchy and renders it like the tree command.
+package tree
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walker holds the state of a single walk.
+type walker struct {
+	dirNum         int
+	errNum         int
+	fileNum        int
+	skipNum        int
+	isEndDir       []bool
+	colored        bool
+	theme          *Theme
+	level          uint
+	permission     bool
+	uid            bool
+	gid            bool
+	size           bool
+	includeDot     bool
+	datetime       bool
+	git            bool
+	gitStatus      *gitRepoStatus
+	highlight      map[string]bool
+	fullPath       bool
+	absolute       bool
+	noIndent       bool
+	noReport       bool
+	format         OutputFormat
+	markdownBase   string
+	htmlBase       string
+	root           string
+	patterns       []string
+	ignorePatterns []string
+	prune          bool
+	dirsOnly       bool
+	fileLimit      int
+	emptyDirs      map[string]bool
+	gitignore      bool
+	ignoreFiles    []string
+	absRoot        string
+	baseIgnore     *ignoreMatcher
+	ignoreMatchers map[string]*ignoreMatcher
+	sortOrder      SortOrder
+	reverse        bool
+	dirsFirst      bool
+	filesFirst     bool
+	du             bool
+	usages         map[string]Usage
+	links          map[[2]uint64]bool
+	usage          Usage
+	sizeFormat     SizeFormat
+	follow         bool
+	visiting       map[[2]uint64]bool
+	parallel       int
+	prefetcher     *prefetcher
+	pathLists      map[string]*PathList
+	listing        *PathList
+	out            io.Writer
+	renderer       Renderer
+}
+
+// newRow returns the row of the entry at path, carrying the display settings.
+// The row gets its own copy of the connector state, as renderers may keep rows.
+// The entries of a path list have no metadata, so their rows show none.
+func (w *walker) newRow(path string, fi os.FileInfo, level uint) Row {
+	row := Row{
+		path:         path,
+		level:        level,
+		onRightAngle: false,
+		isBlank:      append([]bool(nil), w.isEndDir...),
+		colored:      w.colored,
+		theme:        w.theme,
+		permission:   w.permission,
+		uid:          w.uid,
+		gid:          w.gid,
+		size:         w.size || w.du,
+		datetime:     w.datetime,
+		fullPath:     w.fullPath,
+		absolute:     w.absolute,
+		noIndent:     w.noIndent,
+		sizeFormat:   w.sizeFormat,
+		changed:      w.highlight[path],
+	}
+
+	if fi != nil {
+		row.setFile(fi)
+	}
+
+	if w.listing != nil {
+		row.permission, row.uid, row.gid, row.size, row.datetime = false, false, false, false, false
+	}
+
+	if w.gitStatus != nil {
+		row.git = true
+		row.gitStatus = w.gitStatus.status(w.absPath(path))
+	}
+
+	return row
+}
+
+// readDir returns the entries of dir which are to be listed.
+func (w *walker) readDir(dir string) ([]os.FileInfo, error) {
+	var files []os.FileInfo
+	var err error
+
+	var depth uint
+	if w.listing != nil {
+		files = w.listing.readDir(w.relPath(dir))
+	} else if w.prefetcher != nil {
+		depth = w.prefetchDepth(dir)
+		files, err = w.prefetcher.read(dir, depth)
+	} else {
+		files, err = readDirRaw(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The entries read ahead are shared, so they are filtered into a copy.
+	entries := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		file = w.followLink(path, file)
+
+		if !w.isListed(path, file) {
+			continue
+		}
+
+		if w.prune && file.IsDir() && w.isEmptyDir(path, file) {
+			continue
+		}
+
+		entries = append(entries, file)
+	}
+
+	w.sortFiles(entries)
+
+	if w.prefetcher != nil {
+		w.prefetchListed(dir, entries, depth)
+	}
+
+	return entries, nil
+}
+
+func (w *walker) isListed(path string, file os.FileInfo) bool {
+	name := file.Name()
+
+	if !w.includeDot && name[:1] == "." && name != "." {
+		return false
+	}
+
+	if w.isIgnored(path, file) {
+		return false
+	}
+
+	if len(w.ignorePatterns) == 0 && len(w.patterns) == 0 {
+		return true
+	}
+
+	rel := w.relPath(path)
+
+	if matchPatterns(w.ignorePatterns, name, rel) {
+		return false
+	}
+
+	if len(w.patterns) > 0 && !file.IsDir() && !matchPatterns(w.patterns, name, rel) {
+		return false
+	}
+
+	return true
+}
+
+// relPath returns the slash separated path of p relative to the root.
+func (w *walker) relPath(p string) string {
+	rel, err := filepath.Rel(w.root, p)
+	if err != nil {
+		rel = p
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// absPath returns the absolute path of p, which is below the root.
+func (w *walker) absPath(p string) string {
+	return filepath.Join(w.absRoot, filepath.FromSlash(w.relPath(p)))
+}
+
+// initIgnore collects the ignore rules which apply above the root: the
+// global excludes file, .git/info/exclude and the ignore files of the
+// directories between the top of the repository and the root.
+func (w *walker) initIgnore() error {
+	absRoot, err := filepath.Abs(w.root)
+	if err != nil {
+		return err
+	}
+	w.absRoot = absRoot
+
+	top, gitDir := findGitDir(absRoot)
+	base := top
+	if base == "" {
+		base = absRoot
+	}
+
+	var m *ignoreMatcher
+	if w.gitignore {
+		if excludes := globalExcludesFile(gitDir); excludes != "" {
+			m = m.load(excludes, base)
+		}
+
+		if gitDir != "" {
+			m = m.load(filepath.Join(gitDir, "info", "exclude"), base)
+		}
+	}
+
+	if top != "" && top != absRoot {
+		rel, err := filepath.Rel(top, filepath.Dir(absRoot))
+		if err != nil {
+			return err
+		}
+
+		dir := top
+		m = m.loadDir(dir, w.ignoreFiles)
+		if rel != "." {
+			for _, name := range strings.Split(rel, string(filepath.Separator)) {
+				dir = filepath.Join(dir, name)
+				m = m.loadDir(dir, w.ignoreFiles)
+			}
+		}
+	}
+
+	w.baseIgnore = m
+
+	return nil
+}
+
+// ignoreMatcher returns the matcher holding the rules which apply to the entries of dir.
+func (w *walker) ignoreMatcher(dir string) *ignoreMatcher {
+	if m, ok := w.ignoreMatchers[dir]; ok {
+		return m
+	}
+
+	parent := w.baseIgnore
+	if dir != filepath.Clean(w.root) {
+		parent = w.ignoreMatcher(filepath.Dir(dir))
+	}
+
+	m := parent.loadDir(w.absPath(dir), w.ignoreFiles)
+	w.ignoreMatchers[dir] = m
+
+	return m
+}
+
+func (w *walker) isIgnored(p string, file os.FileInfo) bool {
+	if w.listing != nil || (!w.gitignore && len(w.ignoreFiles) == 0) {
+		return false
+	}
+
+	// git never lists its own directory.
+	if w.gitignore && file.Name() == ".git" {
+		return true
+	}
+
+	abs := filepath.ToSlash(w.absPath(p))
+
+	return w.ignoreMatcher(filepath.Dir(p)).ignored(abs, file.IsDir())
+}
+
+// isEmptyDir reports whether dir, whose information is fi, has nothing left
+// to list after filtering. A directory which is already being visited is the
+// target of a link loop and is not empty, as the walk shows it as recursive.
+func (w *walker) isEmptyDir(dir string, fi os.FileInfo) bool {
+	if empty, ok := w.emptyDirs[dir]; ok {
+		return empty
+	}
+
+	id, hasID := dirID(fi)
+	if hasID {
+		if w.visiting[id] {
+			return false
+		}
+
+		w.visiting[id] = true
+		defer delete(w.visiting, id)
+	}
+
+	files, err := w.readDir(dir)
+	files, _ = w.shownFiles(files)
+	empty := err == nil && len(files) == 0
+	w.emptyDirs[dir] = empty
+
+	return empty
+}
+
+// shownFiles drops the entries which are not shown in directories only mode
+// and returns the number of dropped entries.
+func (w *walker) shownFiles(files []os.FileInfo) ([]os.FileInfo, int) {
+	if !w.dirsOnly {
+		return files, 0
+	}
+
+	dirs := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			dirs = append(dirs, file)
+		}
+	}
+
+	return dirs, len(files) - len(dirs)
+}
+
+// walkFiles renders files, the entries of dir. Subdirectories which cannot
+// be read are reported on their row and counted, and the walk goes on.
+func (w *walker) walkFiles(dir string, files []os.FileInfo, level uint) error {
+	files, skipped := w.shownFiles(files)
+	w.skipNum += skipped
+
+	for i, file := range files {
+		if int(level)-len(w.isEndDir) == 1 {
+			w.isEndDir = append(w.isEndDir, false)
+		}
+
+		if int(level) < len(w.isEndDir) {
+			w.isEndDir = w.isEndDir[:level]
+		}
+
+		var onRightAngle bool
+		if i == len(files)-1 {
+			onRightAngle = true
+			w.isEndDir[level-1] = true
+		}
+
+		path := filepath.Join(dir, file.Name())
+
+		row := w.newRow(path, file, level)
+		row.onRightAngle = onRightAngle
+
+		if file.Mode()&os.ModeSymlink != 0 {
+			row.linkTarget, _ = os.Readlink(path)
+			if _, err := os.Stat(path); err != nil {
+				row.brokenLink = true
+			}
+		}
+
+		var id [2]uint64
+		var hasID bool
+		if w.follow && file.IsDir() {
+			id, hasID = dirID(file)
+			row.recursive = hasID && w.visiting[id]
+		}
+
+		if w.du && w.listing == nil {
+			if file.IsDir() && !isFollowedLink(file) {
+				u := w.diskUsage(path, file)
+				row.usage = &u
+			} else {
+				u := fileUsage(file)
+				row.usage = &u
+			}
+		}
+
+		var children []os.FileInfo
+		descend := file.IsDir() && level < w.level && !row.recursive
+		if descend {
+			children, row.err = w.readDir(path)
+			if row.err != nil {
+				descend = false
+				w.errNum++
+			} else if w.fileLimit > 0 && len(children) > w.fileLimit {
+				descend = false
+				row.exceeded = len(children)
+				w.skipNum += len(children)
+			}
+		}
+
+		if err := w.renderer.Entry(row); err != nil {
+			return err
+		}
+
+		if file.IsDir() {
+			if descend {
+				if hasID {
+					w.visiting[id] = true
+				}
+
+				if err := w.renderer.EnterDir(row); err != nil {
+					return err
+				}
+
+				if err := w.walkFiles(path, children, level+1); err != nil {
+					return err
+				}
+
+				if err := w.renderer.LeaveDir(row); err != nil {
+					return err
+				}
+
+				if hasID {
+					delete(w.visiting, id)
+				}
+			}
+
+			if w.prefetcher != nil {
+				w.prefetcher.release(path)
+			}
+
+			w.dirNum++
+		} else {
+			w.fileNum++
+		}
+
+	}
+
+	return nil
+}
+
+// ErrPartial is returned by Tree when some directories could not be read.
+// Everything else has been rendered.
+var ErrPartial = errors.New("some directories could not be read")
+
+// Tree walks root and renders it according to opts.
+func Tree(root string, opts ...Option) error {
+	return Trees([]string{root}, opts...)
+}
+
+// newWalker returns a walker configured by opts.
+func newWalker(opts []Option) *walker {
+	w := &walker{
+		dirNum:         0,
+		errNum:         0,
+		fileNum:        0,
+		skipNum:        0,
+		isEndDir:       []bool{},
+		colored:        true,
+		theme:          nil,
+		level:          math.MaxUint64,
+		permission:     false,
+		uid:            false,
+		gid:            false,
+		size:           false,
+		includeDot:     false,
+		datetime:       false,
+		git:            false,
+		gitStatus:      nil,
+		highlight:      map[string]bool{},
+		fullPath:       false,
+		absolute:       false,
+		noIndent:       false,
+		noReport:       false,
+		format:         OutputText,
+		markdownBase:   "",
+		htmlBase:       "",
+		root:           "",
+		patterns:       []string{},
+		ignorePatterns: []string{},
+		prune:          false,
+		dirsOnly:       false,
+		fileLimit:      0,
+		emptyDirs:      map[string]bool{},
+		gitignore:      false,
+		ignoreFiles:    []string{},
+		absRoot:        "",
+		baseIgnore:     nil,
+		ignoreMatchers: map[string]*ignoreMatcher{},
+		sortOrder:      SortName,
+		reverse:        false,
+		dirsFirst:      false,
+		filesFirst:     false,
+		du:             false,
+		usages:         map[string]Usage{},
+		links:          map[[2]uint64]bool{},
+		usage:          Usage{},
+		follow:         false,
+		visiting:       map[[2]uint64]bool{},
+		parallel:       0,
+		prefetcher:     nil,
+		pathLists:      map[string]*PathList{},
+		listing:        nil,
+		out:            os.Stdout,
+		renderer:       nil,
+	}
+
+	for _, o := range opts {
+		o.apply(w)
+	}
+
+	return w
+}
+
+// Trees walks each of roots in turn and renders them according to opts.
+// The result holds the totals of all of them.
+func Trees(roots []string, opts ...Option) error {
+	w := newWalker(opts)
+
+	if w.parallel > 1 {
+		// The disk usage and the pruning look below the level limit.
+		maxDepth := w.level
+		if w.du || w.prune {
+			maxDepth = math.MaxUint64
+		}
+
+		// Only the listed directories are read ahead when ignore rules may
+		// leave directories out.
+		crawl := !w.gitignore && len(w.ignoreFiles) == 0 && len(w.ignorePatterns) == 0
+
+		w.prefetcher = newPrefetcher(w.parallel, maxDepth, crawl, w.includeDot)
+		defer w.prefetcher.close()
+	}
+
+	if w.renderer == nil {
+		w.renderer = w.builtinRenderer()
+	}
+
+	for _, root := range roots {
+		if err := w.walkRoot(root); err != nil {
+			return err
+		}
+	}
+
+	result := Result{
+		Directories: w.dirNum,
+		Files:       w.fileNum,
+		Errors:      w.errNum,
+		Skipped:     w.skipNum,
+		SizeFormat:  w.sizeFormat,
+		NoReport:    w.noReport,
+	}
+
+	if w.du {
+		result.Usage = &w.usage
+	}
+
+	if err := w.renderer.Finish(result); err != nil {
+		return err
+	}
+
+	if result.Errors > 0 {
+		return ErrPartial
+	}
+
+	return nil
+}
+
+// builtinRenderer returns the renderer of the selected output format.
+func (w *walker) builtinRenderer() Renderer {
+	switch w.format {
+	case OutputJSON:
+		return NewJSONRenderer(w.out)
+	case OutputXML:
+		return NewXMLRenderer(w.out)
+	case OutputDOT:
+		return NewDOTRenderer(w.out)
+	case OutputMermaid:
+		return NewMermaidRenderer(w.out)
+	case OutputMarkdown:
+		return NewMarkdownRenderer(w.out)
+	case OutputMarkdownList:
+		return NewMarkdownListRenderer(w.out, w.markdownBase)
+	case OutputNDJSON:
+		return NewNDJSONRenderer(w.out)
+	case OutputCSV:
+		return NewCSVRenderer(w.out)
+	case OutputHTML:
+		return NewHTMLRenderer(w.out, w.htmlBase)
+	}
+
+	return NewTextRenderer(w.out)
+}
+
+// walkRoot renders a single root. A root which cannot be read is reported
+// on its row and counted as an error.
+func (w *walker) walkRoot(root string) error {
+	w.root = root
+	w.isEndDir = w.isEndDir[:0]
+	w.visiting = map[[2]uint64]bool{}
+	w.ignoreMatchers = map[string]*ignoreMatcher{}
+	w.listing = w.pathLists[root]
+	w.gitStatus = nil
+
+	if w.listing != nil {
+		return w.walkListing(root)
+	}
+
+	if w.gitignore || len(w.ignoreFiles) > 0 {
+		if err := w.initIgnore(); err != nil {
+			return err
+		}
+	}
+
+	if w.git {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		w.absRoot = absRoot
+		w.gitStatus = loadGitStatus(absRoot)
+	}
+
+	row := w.newRow(root, nil, 0)
+	row.onRightAngle = true
+
+	var files []os.FileInfo
+	fi, err := os.Stat(root)
+	if err == nil {
+		row.setFile(fi)
+
+		if w.follow {
+			if id, ok := fileID(fi); ok {
+				w.visiting[id] = true
+			}
+		}
+
+		files, err = w.readDir(root)
+	}
+
+	if err != nil {
+		row.err = err
+		w.errNum++
+	} else {
+		if w.du {
+			u := w.diskUsage(root, fi)
+			row.usage = &u
+			w.usage.add(u)
+		}
+	}
+
+	if err := w.renderer.BeginRoot(row); err != nil {
+		return err
+	}
+
+	if row.err == nil && w.level > 0 {
+		if err := w.walkFiles(root, files, 1); err != nil {
+			return err
+		}
+	}
+
+	if w.prefetcher != nil {
+		w.prefetcher.release(root)
+	}
+
+	return w.renderer.EndRoot(row)
+}
+
+// walkListing renders the path list of root without reading the file system.
+func (w *walker) walkListing(root string) error {
+	row := w.newRow(root, listedFile{name: filepath.Base(root), dir: true}, 0)
+	row.onRightAngle = true
+
+	if err := w.renderer.BeginRoot(row); err != nil {
+		return err
+	}
+
+	if w.level > 0 {
+		files, err := w.readDir(root)
+		if err != nil {
+			return err
+		}
+
+		if err := w.walkFiles(root, files, 1); err != nil {
+			return err
+		}
+	}
+
+	return w.renderer.EndRoot(row)
+}
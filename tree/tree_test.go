@@ -0,0 +1,1868 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const TMP_DIR = "tmp"
+
+func setup() error {
+	files := []string{
+		TMP_DIR + "/.aaa",
+		TMP_DIR + "/.bbb/.ccc",
+		TMP_DIR + "/01/README.md",
+		TMP_DIR + "/01/compiled.o",
+		TMP_DIR + "/01/compressed.zip",
+		TMP_DIR + "/01/crypto.asc",
+		TMP_DIR + "/01/document.xlsx",
+		TMP_DIR + "/01/exec",
+		TMP_DIR + "/01/image.png",
+		TMP_DIR + "/01/music.mp3",
+		TMP_DIR + "/01/tmp.bk",
+		TMP_DIR + "/01/video.mp4",
+		TMP_DIR + "/01/wav.wav",
+		TMP_DIR + "/foo/bar/baz",
+		TMP_DIR + "/foo/qux",
+		TMP_DIR + "/foo/quux",
+		TMP_DIR + "/corge",
+		TMP_DIR + "/grault/garply/waldo/wibble",
+		TMP_DIR + "/grault/garply/waldo/wobble",
+		TMP_DIR + "/grault/garply/fred",
+		TMP_DIR + "/grault/plugh",
+		TMP_DIR + "/xyzzy/thud/wubble",
+		TMP_DIR + "/xyzzy/thud/flob",
+	}
+
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		_, err := os.Create(f)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	// TODO: 個別にパーミッションを付与しているので、struct で管理できるようにする。
+	os.Chmod(TMP_DIR+"/01/exec", 0777)
+
+	return nil
+}
+
+func reset() error {
+	if fi, err := os.Stat(TMP_DIR); err == nil && fi.IsDir() {
+		err := os.RemoveAll(TMP_DIR)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	setup()
+
+	result := m.Run()
+
+	reset()
+
+	os.Exit(result)
+
+}
+func TestTree(t *testing.T) {
+	testCaseWithDate, err := testCaseWithDate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		want       string
+		colored    coloredOption
+		level      levelOption
+		permission permissionOption
+		uid        uidOption
+		gid        gidOption
+		size       sizeOption
+		includeDot includeDotOption
+		datetime   datetimeOption
+	}{
+		{
+			name: "gotree <directory>",
+			want: `tmp
+[90m├── [0m[94m01[0m/
+[90m│   [0m[90m├── [0m[4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[92mexec[0m*
+[90m│   [0m[90m├── [0m[95mimage.png[0m
+[90m│   [0m[90m├── [0m[35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[35mwav.wav[0m
+[90m├── [0mcorge
+[90m├── [0m[94mfoo[0m/
+[90m│   [0m[90m├── [0m[94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0mbaz
+[90m│   [0m[90m├── [0mquux
+[90m│   [0m[90m└── [0mqux
+[90m├── [0m[94mgrault[0m/
+[90m│   [0m[90m├── [0m[94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0mfred
+[90m│   [0m[90m│   [0m[90m└── [0m[94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0mwibble
+[90m│   [0m[90m│   [0m    [90m└── [0mwobble
+[90m│   [0m[90m└── [0mplugh
+[90m└── [0m[94mxyzzy[0m/
+    [90m└── [0m[94mthud[0m/
+        [90m├── [0mflob
+        [90m└── [0mwubble
+
+8 directories, 21 files`,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --disable-color <directory>",
+			want: `tmp
+├── 01
+│   ├── README.md
+│   ├── compiled.o
+│   ├── compressed.zip
+│   ├── crypto.asc
+│   ├── document.xlsx
+│   ├── exec
+│   ├── image.png
+│   ├── music.mp3
+│   ├── tmp.bk
+│   ├── video.mp4
+│   └── wav.wav
+├── corge
+├── foo
+│   ├── bar
+│   │   └── baz
+│   ├── quux
+│   └── qux
+├── grault
+│   ├── garply
+│   │   ├── fred
+│   │   └── waldo
+│   │       ├── wibble
+│   │       └── wobble
+│   └── plugh
+└── xyzzy
+    └── thud
+        ├── flob
+        └── wubble
+
+8 directories, 21 files`,
+			colored:    false,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree -L 2 <directory>",
+			want: `tmp
+[90m├── [0m[94m01[0m/
+[90m│   [0m[90m├── [0m[4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[92mexec[0m*
+[90m│   [0m[90m├── [0m[95mimage.png[0m
+[90m│   [0m[90m├── [0m[35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[35mwav.wav[0m
+[90m├── [0mcorge
+[90m├── [0m[94mfoo[0m/
+[90m│   [0m[90m├── [0m[94mbar[0m/
+[90m│   [0m[90m├── [0mquux
+[90m│   [0m[90m└── [0mqux
+[90m├── [0m[94mgrault[0m/
+[90m│   [0m[90m├── [0m[94mgarply[0m/
+[90m│   [0m[90m└── [0mplugh
+[90m└── [0m[94mxyzzy[0m/
+    [90m└── [0m[94mthud[0m/
+
+7 directories, 15 files`,
+			colored:    true,
+			level:      2,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --disable-color -L 2 <directory>",
+			want: `tmp
+├── 01
+│   ├── README.md
+│   ├── compiled.o
+│   ├── compressed.zip
+│   ├── crypto.asc
+│   ├── document.xlsx
+│   ├── exec
+│   ├── image.png
+│   ├── music.mp3
+│   ├── tmp.bk
+│   ├── video.mp4
+│   └── wav.wav
+├── corge
+├── foo
+│   ├── bar
+│   ├── quux
+│   └── qux
+├── grault
+│   ├── garply
+│   └── plugh
+└── xyzzy
+    └── thud
+
+7 directories, 15 files`,
+			colored:    false,
+			level:      2,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --permission <directory>",
+			want: `tmp
+[90m├── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94m01[0m/
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m[32mx[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m[31mw[0m[32mx[0m]  [92mexec[0m*
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [95mimage.png[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  [35mwav.wav[0m
+[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  corge
+[90m├── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mfoo[0m/
+[90m│   [0m[90m├── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  baz
+[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  quux
+[90m│   [0m[90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  qux
+[90m├── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mgrault[0m/
+[90m│   [0m[90m├── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  fred
+[90m│   [0m[90m│   [0m[90m└── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  wibble
+[90m│   [0m[90m│   [0m    [90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  wobble
+[90m│   [0m[90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  plugh
+[90m└── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mxyzzy[0m/
+    [90m└── [0m[[94md[0m[33mr[0m[31mw[0m[32mx[0m[33mr[0m-[32mx[0m[33mr[0m-[32mx[0m]  [94mthud[0m/
+        [90m├── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  flob
+        [90m└── [0m[.[33mr[0m[31mw[0m-[33mr[0m--[33mr[0m--]  wubble
+
+8 directories, 21 files`,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: true,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --disable-color --permission <directory>",
+			want: `tmp
+├── [drwxr-xr-x]  01
+│   ├── [.rw-r--r--]  README.md
+│   ├── [.rw-r--r--]  compiled.o
+│   ├── [.rw-r--r--]  compressed.zip
+│   ├── [.rw-r--r--]  crypto.asc
+│   ├── [.rw-r--r--]  document.xlsx
+│   ├── [.rwxrwxrwx]  exec
+│   ├── [.rw-r--r--]  image.png
+│   ├── [.rw-r--r--]  music.mp3
+│   ├── [.rw-r--r--]  tmp.bk
+│   ├── [.rw-r--r--]  video.mp4
+│   └── [.rw-r--r--]  wav.wav
+├── [.rw-r--r--]  corge
+├── [drwxr-xr-x]  foo
+│   ├── [drwxr-xr-x]  bar
+│   │   └── [.rw-r--r--]  baz
+│   ├── [.rw-r--r--]  quux
+│   └── [.rw-r--r--]  qux
+├── [drwxr-xr-x]  grault
+│   ├── [drwxr-xr-x]  garply
+│   │   ├── [.rw-r--r--]  fred
+│   │   └── [drwxr-xr-x]  waldo
+│   │       ├── [.rw-r--r--]  wibble
+│   │       └── [.rw-r--r--]  wobble
+│   └── [.rw-r--r--]  plugh
+└── [drwxr-xr-x]  xyzzy
+    └── [drwxr-xr-x]  thud
+        ├── [.rw-r--r--]  flob
+        └── [.rw-r--r--]  wubble
+
+8 directories, 21 files`,
+			colored:    false,
+			level:      math.MaxInt64,
+			permission: true,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree -a <directory>",
+			want: `tmp
+[90m├── [0m.aaa
+[90m├── [0m[94m.bbb[0m/
+[90m│   [0m[90m└── [0m.ccc
+[90m├── [0m[94m01[0m/
+[90m│   [0m[90m├── [0m[4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[92mexec[0m*
+[90m│   [0m[90m├── [0m[95mimage.png[0m
+[90m│   [0m[90m├── [0m[35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[35mwav.wav[0m
+[90m├── [0mcorge
+[90m├── [0m[94mfoo[0m/
+[90m│   [0m[90m├── [0m[94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0mbaz
+[90m│   [0m[90m├── [0mquux
+[90m│   [0m[90m└── [0mqux
+[90m├── [0m[94mgrault[0m/
+[90m│   [0m[90m├── [0m[94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0mfred
+[90m│   [0m[90m│   [0m[90m└── [0m[94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0mwibble
+[90m│   [0m[90m│   [0m    [90m└── [0mwobble
+[90m│   [0m[90m└── [0mplugh
+[90m└── [0m[94mxyzzy[0m/
+    [90m└── [0m[94mthud[0m/
+        [90m├── [0mflob
+        [90m└── [0mwubble
+
+9 directories, 23 files`,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: true,
+			datetime:   false,
+		},
+		{
+			name: "gotree --disable-color -a <directory>",
+			want: `tmp
+├── .aaa
+├── .bbb
+│   └── .ccc
+├── 01
+│   ├── README.md
+│   ├── compiled.o
+│   ├── compressed.zip
+│   ├── crypto.asc
+│   ├── document.xlsx
+│   ├── exec
+│   ├── image.png
+│   ├── music.mp3
+│   ├── tmp.bk
+│   ├── video.mp4
+│   └── wav.wav
+├── corge
+├── foo
+│   ├── bar
+│   │   └── baz
+│   ├── quux
+│   └── qux
+├── grault
+│   ├── garply
+│   │   ├── fred
+│   │   └── waldo
+│   │       ├── wibble
+│   │       └── wobble
+│   └── plugh
+└── xyzzy
+    └── thud
+        ├── flob
+        └── wubble
+
+9 directories, 23 files`,
+			colored:    false,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: true,
+			datetime:   false,
+		},
+		{
+			// This test case was created for "github actions". uid has a value according to it.
+			// TODO: allow user group to be specified.
+			name: "gotree --uid --gid <directory>",
+			want: `tmp
+[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [94m01[0m/
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [92mexec[0m*
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [95mimage.png[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[[33mrunner[0m [33mdocker[0m]  [35mwav.wav[0m
+[90m├── [0m[[33mrunner[0m [33mdocker[0m]  corge
+[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [94mfoo[0m/
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0m[[33mrunner[0m [33mdocker[0m]  baz
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  quux
+[90m│   [0m[90m└── [0m[[33mrunner[0m [33mdocker[0m]  qux
+[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [94mgrault[0m/
+[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  [94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0m[[33mrunner[0m [33mdocker[0m]  fred
+[90m│   [0m[90m│   [0m[90m└── [0m[[33mrunner[0m [33mdocker[0m]  [94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0m[[33mrunner[0m [33mdocker[0m]  wibble
+[90m│   [0m[90m│   [0m    [90m└── [0m[[33mrunner[0m [33mdocker[0m]  wobble
+[90m│   [0m[90m└── [0m[[33mrunner[0m [33mdocker[0m]  plugh
+[90m└── [0m[[33mrunner[0m [33mdocker[0m]  [94mxyzzy[0m/
+    [90m└── [0m[[33mrunner[0m [33mdocker[0m]  [94mthud[0m/
+        [90m├── [0m[[33mrunner[0m [33mdocker[0m]  flob
+        [90m└── [0m[[33mrunner[0m [33mdocker[0m]  wubble
+
+8 directories, 21 files`,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        true,
+			gid:        true,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			// This test case was created for "github actions". uid has a value according to it.
+			// TODO: allow user group to be specified.
+			name: "gotree --disable-color --uid --gid <directory>",
+			want: `tmp
+├── [runner docker]  01
+│   ├── [runner docker]  README.md
+│   ├── [runner docker]  compiled.o
+│   ├── [runner docker]  compressed.zip
+│   ├── [runner docker]  crypto.asc
+│   ├── [runner docker]  document.xlsx
+│   ├── [runner docker]  exec
+│   ├── [runner docker]  image.png
+│   ├── [runner docker]  music.mp3
+│   ├── [runner docker]  tmp.bk
+│   ├── [runner docker]  video.mp4
+│   └── [runner docker]  wav.wav
+├── [runner docker]  corge
+├── [runner docker]  foo
+│   ├── [runner docker]  bar
+│   │   └── [runner docker]  baz
+│   ├── [runner docker]  quux
+│   └── [runner docker]  qux
+├── [runner docker]  grault
+│   ├── [runner docker]  garply
+│   │   ├── [runner docker]  fred
+│   │   └── [runner docker]  waldo
+│   │       ├── [runner docker]  wibble
+│   │       └── [runner docker]  wobble
+│   └── [runner docker]  plugh
+└── [runner docker]  xyzzy
+    └── [runner docker]  thud
+        ├── [runner docker]  flob
+        └── [runner docker]  wubble
+
+8 directories, 21 files`,
+			colored:    false,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        true,
+			gid:        true,
+			size:       false,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --size <directory>",
+			want: `tmp
+[90m├── [0m[-]  [94m01[0m/
+[90m│   [0m[90m├── [0m[[32m0[0m]  [4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [92mexec[0m*
+[90m│   [0m[90m├── [0m[[32m0[0m]  [95mimage.png[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[[32m0[0m]  [35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[[32m0[0m]  [35mwav.wav[0m
+[90m├── [0m[[32m0[0m]  corge
+[90m├── [0m[-]  [94mfoo[0m/
+[90m│   [0m[90m├── [0m[-]  [94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0m[[32m0[0m]  baz
+[90m│   [0m[90m├── [0m[[32m0[0m]  quux
+[90m│   [0m[90m└── [0m[[32m0[0m]  qux
+[90m├── [0m[-]  [94mgrault[0m/
+[90m│   [0m[90m├── [0m[-]  [94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0m[[32m0[0m]  fred
+[90m│   [0m[90m│   [0m[90m└── [0m[-]  [94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0m[[32m0[0m]  wibble
+[90m│   [0m[90m│   [0m    [90m└── [0m[[32m0[0m]  wobble
+[90m│   [0m[90m└── [0m[[32m0[0m]  plugh
+[90m└── [0m[-]  [94mxyzzy[0m/
+    [90m└── [0m[-]  [94mthud[0m/
+        [90m├── [0m[[32m0[0m]  flob
+        [90m└── [0m[[32m0[0m]  wubble
+
+8 directories, 21 files`,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       true,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name: "gotree --disable-color --size <directory>",
+			want: `tmp
+├── [-]  01
+│   ├── [0]  README.md
+│   ├── [0]  compiled.o
+│   ├── [0]  compressed.zip
+│   ├── [0]  crypto.asc
+│   ├── [0]  document.xlsx
+│   ├── [0]  exec
+│   ├── [0]  image.png
+│   ├── [0]  music.mp3
+│   ├── [0]  tmp.bk
+│   ├── [0]  video.mp4
+│   └── [0]  wav.wav
+├── [0]  corge
+├── [-]  foo
+│   ├── [-]  bar
+│   │   └── [0]  baz
+│   ├── [0]  quux
+│   └── [0]  qux
+├── [-]  grault
+│   ├── [-]  garply
+│   │   ├── [0]  fred
+│   │   └── [-]  waldo
+│   │       ├── [0]  wibble
+│   │       └── [0]  wobble
+│   └── [0]  plugh
+└── [-]  xyzzy
+    └── [-]  thud
+        ├── [0]  flob
+        └── [0]  wubble
+
+8 directories, 21 files`,
+			colored:    false,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       true,
+			includeDot: false,
+			datetime:   false,
+		},
+		{
+			name:       "gotree -D <directory>",
+			want:       testCaseWithDate,
+			colored:    true,
+			level:      math.MaxInt64,
+			permission: false,
+			uid:        false,
+			gid:        false,
+			size:       false,
+			includeDot: false,
+			datetime:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpStdout := os.Stdout
+
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := Tree(TMP_DIR, tt.colored, tt.level, tt.permission, tt.uid, tt.gid, tt.size, tt.includeDot, tt.datetime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := strings.TrimRight(buf.String(), "\n")
+
+			os.Stdout = tmpStdout
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Stdout missmatch (-got +want):\n%s", diff)
+			}
+		})
+
+	}
+
+}
+
+func TestTreeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := Tree(TMP_DIR, coloredOption(false), levelOption(2), formatOption(OutputJSON), writerOption{&buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc []json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc) != 2 {
+		t.Fatalf("got %d elements, want 2", len(doc))
+	}
+
+	var root jsonEntry
+	if err := json.Unmarshal(doc[0], &root); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range root.Children {
+		names = append(names, c.Type+":"+c.Path)
+	}
+
+	want := []string{
+		"directory:tmp/01",
+		"file:tmp/corge",
+		"directory:tmp/foo",
+		"directory:tmp/grault",
+		"directory:tmp/xyzzy",
+	}
+	if diff := cmp.Diff(names, want); diff != "" {
+		t.Errorf("children missmatch (-got +want):\n%s", diff)
+	}
+
+	if root.Name != TMP_DIR || root.Type != "directory" {
+		t.Errorf("unexpected root: %s %s", root.Type, root.Name)
+	}
+
+	if exec := root.Children[0].Children[5]; exec.Name != "exec" || exec.Mode == nil || *exec.Mode != 0777 {
+		t.Errorf("unexpected entry: %s %v", exec.Name, exec.Mode)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(doc[1], &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(report, jsonReport{Type: "report", Directories: 7, Files: 15}); diff != "" {
+		t.Errorf("report missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestTreeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	err := Tree(TMP_DIR, WithColor(false), WithLevel(2), WithPattern("*.png|*.md|qux"), WithPrune(true), WithFormat(OutputHTML), WithHTMLLinks("https://example.com/files"), WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "<!DOCTYPE html>") || !strings.HasSuffix(got, "</html>\n") {
+		t.Fatalf("not an HTML page:\n%s", got)
+	}
+
+	got = got[strings.Index(got, `<ul class="tree">`):]
+	want := `<ul class="tree">
+<li><details open><summary><a class="dir" href="https://example.com/files/">tmp</a></summary>
+<ul>
+<li><details open><summary><a class="dir" href="https://example.com/files/01/">01</a></summary>
+<ul>
+<li><a class="immediate" href="https://example.com/files/01/README.md">README.md</a></li>
+<li><a class="image" href="https://example.com/files/01/image.png">image.png</a></li>
+</ul>
+</details></li>
+<li><details open><summary><a class="dir" href="https://example.com/files/foo/">foo</a></summary>
+<ul>
+<li><a href="https://example.com/files/foo/qux">qux</a></li>
+</ul>
+</details></li>
+</ul>
+</details></li>
+</ul>
+<p class="report">2 directories, 3 files</p>
+</body>
+</html>
+`
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	// Directories which are not descended into are plain entries.
+	buf.Reset()
+	err = Tree(TMP_DIR+"/foo", WithColor(false), WithLevel(1), WithPermission(true), WithFormat(OutputHTML), WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := fmt.Sprintf(`<li><span class="status">[%s]  </span><span class="dir">bar</span></li>`, mustLstat(t, TMP_DIR+"/foo/bar").Mode())
+	if !strings.Contains(buf.String(), entry) {
+		t.Errorf("output does not contain %s:\n%s", entry, buf.String())
+	}
+}
+
+func TestTreeXML(t *testing.T) {
+	var buf bytes.Buffer
+	err := Tree(TMP_DIR, WithColor(false), WithLevel(2), WithPattern("*.png|*.md|qux"), WithPrune(true), WithFormat(OutputXML), WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<tree>
+  <directory name="tmp">
+    <directory name="01">
+      <file name="README.md"></file>
+      <file name="image.png"></file>
+    </directory>
+    <directory name="foo">
+      <file name="qux"></file>
+    </directory>
+  </directory>
+  <report>
+    <directories>2</directories>
+    <files>3</files>
+  </report>
+</tree>
+`
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestTreeGraph(t *testing.T) {
+	tests := []struct {
+		name   string
+		format OutputFormat
+		want   string
+	}{
+		{
+			name:   "gotree --format=dot -L 2 -P '*.png|*.md|qux' --prune <directory>",
+			format: OutputDOT,
+			want: `digraph tree {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor="#ffffff", fontname="monospace"];
+  n0 [label="tmp", shape=folder, fillcolor="#3b8eea"];
+  n1 [label="01", shape=folder, fillcolor="#3b8eea"];
+  n0 -> n1;
+  n2 [label="README.md", fillcolor="#f5f543"];
+  n1 -> n2;
+  n3 [label="image.png", fillcolor="#d670d6"];
+  n1 -> n3;
+  n4 [label="foo", shape=folder, fillcolor="#3b8eea"];
+  n0 -> n4;
+  n5 [label="qux"];
+  n4 -> n5;
+  // 2 directories, 3 files
+}
+`,
+		},
+		{
+			name:   "gotree --format=mermaid -L 2 -P '*.png|*.md|qux' --prune <directory>",
+			format: OutputMermaid,
+			want: `graph LR
+  n0["tmp"]:::dir
+  n0 --> n1["01"]:::dir
+  n1 --> n2["README.md"]:::immediate
+  n1 --> n3["image.png"]:::image
+  n0 --> n4["foo"]:::dir
+  n4 --> n5["qux"]
+  classDef dir fill:#3b8eea
+  classDef immediate fill:#f5f543
+  classDef image fill:#d670d6
+  %% 2 directories, 3 files
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Tree(TMP_DIR, WithColor(false), WithLevel(2), WithPattern("*.png|*.md|qux"), WithPrune(true), WithFormat(tt.format), WithWriter(&buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(buf.String(), tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTreeMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree --format=markdown <directory>",
+			want: "```\ntmp\n├── 01\n│   ├── README.md\n│   └── image.png\n└── foo\n    └── qux\n\n2 directories, 3 files\n```\n",
+			opts: []Option{WithFormat(OutputMarkdown)},
+		},
+		{
+			name: "gotree --format=markdown-list <directory>",
+			want: `- tmp
+  - 01
+    - README.md
+    - image.png
+  - foo
+    - qux
+
+2 directories, 3 files
+`,
+			opts: []Option{WithFormat(OutputMarkdownList)},
+		},
+		{
+			name: "gotree --format=markdown-list --links <baseURL> <directory>",
+			want: `- [tmp](https://example.com/files/)
+  - [01](https://example.com/files/01/)
+    - [README.md](https://example.com/files/01/README.md)
+    - [image.png](https://example.com/files/01/image.png)
+  - [foo](https://example.com/files/foo/)
+    - [qux](https://example.com/files/foo/qux)
+
+2 directories, 3 files
+`,
+			opts: []Option{WithFormat(OutputMarkdownList), WithMarkdownLinks("https://example.com/files")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append([]Option{WithLevel(2), WithPattern("*.png|*.md|qux"), WithPrune(true), WithWriter(&buf)}, tt.opts...)
+			if err := Tree(TMP_DIR, opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(buf.String(), tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTreeRecords(t *testing.T) {
+	opts := []Option{WithLevel(2), WithPattern("*.png|*.md|qux"), WithPrune(true)}
+	paths := []string{TMP_DIR, TMP_DIR + "/01", TMP_DIR + "/01/README.md", TMP_DIR + "/01/image.png", TMP_DIR + "/foo", TMP_DIR + "/foo/qux"}
+
+	var want []record
+	for _, p := range paths {
+		fi := mustLstat(t, p)
+		row := Row{path: p, level: uint(strings.Count(p, "/"))}
+		row.setFile(fi)
+		want = append(want, newRecord(&row))
+	}
+
+	t.Run("gotree --format=ndjson <directory>", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Tree(TMP_DIR, append(opts, WithFormat(OutputNDJSON), WithWriter(&buf))...); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []record
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var r record
+			if err := dec.Decode(&r); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, r)
+		}
+
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("output missmatch (-got +want):\n%s", diff)
+		}
+	})
+
+	t.Run("gotree --format=csv <directory>", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Tree(TMP_DIR, append(opts, WithFormat(OutputCSV), WithWriter(&buf))...); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantFields := [][]string{recordHeader}
+		for _, r := range want {
+			wantFields = append(wantFields, r.fields())
+		}
+
+		if diff := cmp.Diff(got, wantFields); diff != "" {
+			t.Errorf("output missmatch (-got +want):\n%s", diff)
+		}
+	})
+
+	// A directory which is not opened because of the file limit is not
+	// mistaken for an empty one.
+	t.Run("gotree --format=ndjson --filelimit 2 <directory>", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Tree(TMP_DIR, WithFormat(OutputNDJSON), WithFileLimit(2), WithWriter(&buf)); err != nil {
+			t.Fatal(err)
+		}
+
+		exceeded := map[string]int{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var r record
+			if err := dec.Decode(&r); err != nil {
+				t.Fatal(err)
+			}
+			if r.Exceeded > 0 {
+				exceeded[r.Path] = r.Exceeded
+			}
+		}
+
+		want := map[string]int{TMP_DIR + "/01": 11, TMP_DIR + "/foo": 3}
+		if diff := cmp.Diff(exceeded, want); diff != "" {
+			t.Errorf("output missmatch (-got +want):\n%s", diff)
+		}
+	})
+}
+
+func TestTreeFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree -P '*.md|*.o' <directory>",
+			want: `tmp
+├── 01
+│   ├── README.md
+│   └── compiled.o
+├── foo
+│   └── bar
+├── grault
+│   └── garply
+│       └── waldo
+└── xyzzy
+    └── thud
+
+8 directories, 2 files`,
+			opts: []Option{WithPattern("*.md|*.o")},
+		},
+		{
+			name: "gotree -P '*.md|*.o' --prune <directory>",
+			want: `tmp
+└── 01
+    ├── README.md
+    └── compiled.o
+
+1 directories, 2 files`,
+			opts: []Option{WithPattern("*.md|*.o"), WithPrune(true)},
+		},
+		{
+			name: "gotree -I 01 -I 'grault/**/w*' <directory>",
+			want: `tmp
+├── corge
+├── foo
+│   ├── bar
+│   │   └── baz
+│   ├── quux
+│   └── qux
+├── grault
+│   ├── garply
+│   │   └── fred
+│   └── plugh
+└── xyzzy
+    └── thud
+        ├── flob
+        └── wubble
+
+6 directories, 8 files`,
+			opts: []Option{WithIgnorePattern("01"), WithIgnorePattern("grault/**/w*")},
+		},
+		{
+			name: "gotree -a -I '.aaa|0*|[fgx]*' <directory>",
+			want: `tmp
+├── .bbb
+│   └── .ccc
+└── corge
+
+1 directories, 2 files`,
+			opts: []Option{WithIncludeDot(true), WithIgnorePattern(".aaa|0*|[fgx]*")},
+		},
+		{
+			name: "gotree -d <directory>",
+			want: `tmp
+├── 01
+├── foo
+│   └── bar
+├── grault
+│   └── garply
+│       └── waldo
+└── xyzzy
+    └── thud
+
+8 directories, 0 files, 21 skipped`,
+			opts: []Option{WithDirsOnly(true)},
+		},
+		{
+			name: "gotree --filelimit 2 <directory>",
+			want: `tmp
+├── 01  [11 entries exceeds filelimit]
+├── corge
+├── foo  [3 entries exceeds filelimit]
+├── grault
+│   ├── garply
+│   │   ├── fred
+│   │   └── waldo
+│   │       ├── wibble
+│   │       └── wobble
+│   └── plugh
+└── xyzzy
+    └── thud
+        ├── flob
+        └── wubble
+
+7 directories, 7 files, 14 skipped`,
+			opts: []Option{WithFileLimit(2)},
+		},
+		{
+			name: "gotree -d --filelimit 2 <directory>",
+			want: `tmp
+├── 01  [11 entries exceeds filelimit]
+├── foo  [3 entries exceeds filelimit]
+├── grault
+│   └── garply
+│       └── waldo
+└── xyzzy
+    └── thud
+
+7 directories, 0 files, 21 skipped`,
+			opts: []Option{WithDirsOnly(true), WithFileLimit(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoloredTree(t, TMP_DIR, tt.opts...)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+
+	// The marker is colored like [error opening dir].
+	row := Row{exceeded: 3, err: errors.New("denied"), colored: true}
+	row.setFile(mustLstat(t, TMP_DIR+"/foo"))
+	file := row.File()
+	for _, want := range []string{colorLightRed("[3 entries exceeds filelimit]"), colorLightRed("[error opening dir]")} {
+		if !strings.Contains(file, want) {
+			t.Errorf("%q does not contain %q", file, want)
+		}
+	}
+}
+
+func TestTreeSort(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree --sort=ext <directory>",
+			root: TMP_DIR + "/01",
+			want: `tmp/01
+├── exec
+├── crypto.asc
+├── tmp.bk
+├── README.md
+├── music.mp3
+├── video.mp4
+├── compiled.o
+├── image.png
+├── wav.wav
+├── document.xlsx
+└── compressed.zip
+
+0 directories, 11 files`,
+			opts: []Option{WithSort(SortExtension)},
+		},
+		{
+			name: "gotree -r --dirsfirst -L 1 <directory>",
+			root: TMP_DIR,
+			want: `tmp
+├── xyzzy
+├── grault
+├── foo
+├── 01
+└── corge
+
+4 directories, 1 files`,
+			opts: []Option{WithReverse(true), WithDirsFirst(true), WithLevel(1)},
+		},
+		{
+			name: "gotree --filesfirst <directory>",
+			root: TMP_DIR + "/grault",
+			want: `tmp/grault
+├── plugh
+└── garply
+    ├── fred
+    └── waldo
+        ├── wibble
+        └── wobble
+
+2 directories, 4 files`,
+			opts: []Option{WithFilesFirst(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoloredTree(t, tt.root, tt.opts...)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+// uncoloredTree returns the uncolored output of Tree without the trailing newline.
+func uncoloredTree(t *testing.T, root string, opts ...Option) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	opts = append([]Option{WithColor(false), WithWriter(&buf)}, opts...)
+	if err := Tree(root, opts...); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func TestTreeIgnoreFile(t *testing.T) {
+	ignoreFile := TMP_DIR + "/grault/.gotreeignore"
+	content := "# comment\nwi*\n!wobble\n/plugh\nfred/\n"
+	if err := ioutil.WriteFile(ignoreFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(ignoreFile)
+
+	var buf bytes.Buffer
+	err := Tree(TMP_DIR+"/grault", WithColor(false), WithWriter(&buf), WithIgnoreFile(".gotreeignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `tmp/grault
+└── garply
+    ├── fred
+    └── waldo
+        └── wobble
+
+2 directories, 2 files
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestTreeDU(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 200), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A hard link is counted once.
+	if err := os.Link(filepath.Join(dir, "sub", "b"), filepath.Join(dir, "sub", "c")); err != nil {
+		t.Fatal(err)
+	}
+
+	rootInfo, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subInfo, err := os.Stat(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Tree(dir, WithDU(true), WithFormat(OutputJSON), WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc []json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(doc[1], &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := rootInfo.Size() + subInfo.Size() + 300; report.Size != want {
+		t.Errorf("got total size %d, want %d", report.Size, want)
+	}
+
+	var root jsonEntry
+	if err := json.Unmarshal(doc[0], &root); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := root.Children[1]
+	if want := subInfo.Size() + 200; sub.Name != "sub" || sub.Size == nil || *sub.Size != want {
+		t.Fatalf("unexpected size of %s: %v", sub.Name, sub.Size)
+	}
+
+	// The text output shows the allocated space next to the apparent size.
+	got := uncoloredTree(t, dir, WithDU(true), WithSizeFormat(SizeBytes))
+	lines := strings.Split(got, "\n")
+	if want := fmt.Sprintf("└── [%d %d on disk]  sub", *sub.Size, sub.Allocated); lines[2] != want {
+		t.Errorf("got %q, want %q", lines[2], want)
+	}
+	if want := fmt.Sprintf("%d (%d on disk) used in 1 directories, 3 files", report.Size, report.Allocated); lines[len(lines)-1] != want {
+		t.Errorf("got %q, want %q", lines[len(lines)-1], want)
+	}
+}
+
+func TestTreeSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "d", "e"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Create(filepath.Join(dir, "d", "f")); err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{
+		"broken":  "nowhere",
+		"dl":      "d",
+		"fl":      "d/f",
+		"d/e/up":  "../../d",
+		"d/e/top": "../..",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree <directory>",
+			want: `├── broken -> nowhere
+├── d
+│   ├── e
+│   │   ├── top -> ../..
+│   │   └── up -> ../../d
+│   └── f
+├── dl -> d
+└── fl -> d/f
+
+2 directories, 6 files`,
+			opts: []Option{},
+		},
+		{
+			name: "gotree -l <directory>",
+			want: `├── broken -> nowhere
+├── d
+│   ├── e
+│   │   ├── top -> ../..  [recursive, not followed]
+│   │   └── up -> ../../d  [recursive, not followed]
+│   └── f
+├── dl -> d
+│   ├── e
+│   │   ├── top -> ../..  [recursive, not followed]
+│   │   └── up -> ../../d  [recursive, not followed]
+│   └── f
+└── fl -> d/f
+
+8 directories, 4 files`,
+			opts: []Option{WithFollow(true)},
+		},
+		{
+			name: "gotree --prune -l <directory>",
+			want: `├── broken -> nowhere
+├── d
+│   ├── e
+│   │   ├── top -> ../..  [recursive, not followed]
+│   │   └── up -> ../../d  [recursive, not followed]
+│   └── f
+├── dl -> d
+│   ├── e
+│   │   ├── top -> ../..  [recursive, not followed]
+│   │   └── up -> ../../d  [recursive, not followed]
+│   └── f
+└── fl -> d/f
+
+8 directories, 4 files`,
+			opts: []Option{WithFollow(true), WithPrune(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoloredTree(t, dir, tt.opts...)
+			got = strings.TrimPrefix(got, dir+"\n")
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+
+	// The disk usage does not descend into the link loop.
+	got := uncoloredTree(t, dir, WithFollow(true), WithDU(true))
+	if want := "used in 8 directories, 4 files"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+
+	row := Row{linkTarget: "nowhere", brokenLink: true, colored: true}
+	row.setFile(mustLstat(t, filepath.Join(dir, "broken")))
+	if got, want := row.Name(), colorLightRed("broken")+" -> "+colorLightRed("nowhere"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTreeUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{"a/locked", "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Create(filepath.Join(dir, "b", "c")); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := filepath.Join(dir, "a", "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	var buf bytes.Buffer
+	err = Tree(dir, WithColor(false), WithWriter(&buf))
+	if !errors.Is(err, ErrPartial) {
+		t.Fatalf("got error %v, want %v", err, ErrPartial)
+	}
+
+	want := `├── a
+│   └── locked  [error opening dir]
+└── b
+    └── c
+
+3 directories, 1 files, 1 errors
+`
+	got := strings.TrimPrefix(buf.String(), dir+"\n")
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	// The records tell the unreadable directory from an empty one.
+	var records bytes.Buffer
+	if err := Tree(dir, WithFormat(OutputCSV), WithWriter(&records)); !errors.Is(err, ErrPartial) {
+		t.Fatalf("got error %v, want %v", err, ErrPartial)
+	}
+
+	if !strings.Contains(records.String(), ",open "+locked+": permission denied,\n") {
+		t.Errorf("output does not report the error of %s:\n%s", locked, records.String())
+	}
+}
+
+func TestTrees(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Trees([]string{TMP_DIR + "/foo", TMP_DIR + "/nonexistent", TMP_DIR + "/xyzzy"}, WithColor(false), WithWriter(&buf))
+	if !errors.Is(err, ErrPartial) {
+		t.Fatalf("got error %v, want %v", err, ErrPartial)
+	}
+
+	want := `tmp/foo
+├── bar
+│   └── baz
+├── quux
+└── qux
+tmp/nonexistent  [error opening dir]
+tmp/xyzzy
+└── thud
+    ├── flob
+    └── wubble
+
+2 directories, 5 files, 1 errors
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestTreePath(t *testing.T) {
+	abs, err := filepath.Abs(TMP_DIR + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		root string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree -f <directory>",
+			root: TMP_DIR + "/foo",
+			want: `tmp/foo
+├── tmp/foo/bar
+│   └── tmp/foo/bar/baz
+├── tmp/foo/quux
+└── tmp/foo/qux
+
+1 directories, 3 files`,
+			opts: []Option{WithFullPath(true)},
+		},
+		{
+			name: "gotree -i -f <directory>",
+			root: TMP_DIR + "/foo",
+			want: `tmp/foo
+tmp/foo/bar
+tmp/foo/bar/baz
+tmp/foo/quux
+tmp/foo/qux
+
+1 directories, 3 files`,
+			opts: []Option{WithNoIndent(true), WithFullPath(true)},
+		},
+		{
+			name: "gotree -i --abs <directory>",
+			root: TMP_DIR + "/foo",
+			want: abs + `
+` + abs + `/bar
+` + abs + `/bar/baz
+` + abs + `/quux
+` + abs + `/qux
+
+1 directories, 3 files`,
+			opts: []Option{WithNoIndent(true), WithAbsPath(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoloredTree(t, tt.root, tt.opts...)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTreeNoReport(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		opts []Option
+	}{
+		{
+			// Each line is a path, as xargs reads them.
+			name: "gotree -f -i --noreport <directory>",
+			want: "tmp/foo\ntmp/foo/bar\ntmp/foo/bar/baz\ntmp/foo/quux\ntmp/foo/qux\n",
+			opts: []Option{WithFullPath(true), WithNoIndent(true)},
+		},
+		{
+			name: "gotree --noreport --format=markdown <directory>",
+			want: "```\ntmp/foo\n├── bar\n│   └── baz\n├── quux\n└── qux\n```\n",
+			opts: []Option{WithFormat(OutputMarkdown)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append(tt.opts, WithColor(false), WithNoReport(true), WithWriter(&buf))
+			if err := Tree(TMP_DIR+"/foo", opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(buf.String(), tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+
+	for _, format := range []OutputFormat{OutputJSON, OutputXML, OutputHTML, OutputDOT, OutputMermaid, OutputMarkdownList} {
+		var buf bytes.Buffer
+		if err := Tree(TMP_DIR+"/foo", WithColor(false), WithFormat(format), WithNoReport(true), WithWriter(&buf)); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(buf.String(), "1 directories") || strings.Contains(buf.String(), "report") {
+			t.Errorf("format %d prints the report with WithNoReport:\n%s", format, buf.String())
+		}
+	}
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fi
+}
+
+type recordRenderer struct {
+	events []string
+}
+
+func (r *recordRenderer) BeginRoot(root Row) error {
+	r.events = append(r.events, "root "+root.Path())
+	return nil
+}
+
+func (r *recordRenderer) EnterDir(row Row) error {
+	r.events = append(r.events, "enter "+row.Path())
+	return nil
+}
+
+func (r *recordRenderer) Entry(row Row) error {
+	r.events = append(r.events, "entry "+row.Path())
+	return nil
+}
+
+func (r *recordRenderer) LeaveDir(row Row) error {
+	r.events = append(r.events, "leave "+row.Path())
+	return nil
+}
+
+func (r *recordRenderer) EndRoot(root Row) error {
+	r.events = append(r.events, "end "+root.Path())
+	return nil
+}
+
+func (r *recordRenderer) Finish(result Result) error {
+	r.events = append(r.events, fmt.Sprintf("finish %d %d", result.Directories, result.Files))
+	return nil
+}
+
+func TestTreePathList(t *testing.T) {
+	list := NewPathList("src/main.go", "src/img/logo.png", "README.md", "docs/", "src/lib/a.tar.gz", "bin/run")
+
+	tests := []struct {
+		name string
+		want string
+		opts []Option
+	}{
+		{
+			name: "gotree --fromfile",
+			want: `.
+├── README.md
+├── bin
+│   └── run
+├── docs
+└── src
+    ├── img
+    │   └── logo.png
+    ├── lib
+    │   └── a.tar.gz
+    └── main.go
+
+5 directories, 5 files`,
+		},
+		{
+			name: "gotree --fromfile -p -s -P '*.png' --prune",
+			want: `.
+└── src
+    └── img
+        └── logo.png
+
+2 directories, 1 files`,
+			opts: []Option{WithPermission(true), WithSize(true), WithPattern("*.png"), WithPrune(true)},
+		},
+		{
+			name: "gotree --fromfile -d -L 1",
+			want: `.
+├── bin
+├── docs
+└── src
+
+3 directories, 0 files, 1 skipped`,
+			opts: []Option{WithDirsOnly(true), WithLevel(1)},
+		},
+		{
+			name: "gotree --fromfile --sort=none",
+			want: `.
+├── src
+│   ├── main.go
+│   ├── img
+│   │   └── logo.png
+│   └── lib
+│       └── a.tar.gz
+├── README.md
+├── docs
+└── bin
+    └── run
+
+5 directories, 5 files`,
+			opts: []Option{WithSort(SortNone)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoloredTree(t, ".", append(tt.opts, WithPathList(".", list))...)
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+
+	// The structured outputs leave out the metadata, which is not known.
+	var csv bytes.Buffer
+	if err := Tree(".", WithFormat(OutputCSV), WithPathList(".", NewPathList("a/b")), WithWriter(&csv)); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCSV := `path,depth,type,mode,uid,gid,size,mtime,target,error,exceeded
+.,0,directory,,,,,,,,
+a,1,directory,,,,,,,,
+a/b,2,file,,,,,,,,
+`
+	if diff := cmp.Diff(csv.String(), wantCSV); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	var ndjson bytes.Buffer
+	if err := Tree(".", WithFormat(OutputNDJSON), WithPathList(".", NewPathList("a/b")), WithWriter(&ndjson)); err != nil {
+		t.Fatal(err)
+	}
+
+	wantNDJSON := `{"path":".","depth":0,"type":"directory"}
+{"path":"a","depth":1,"type":"directory"}
+{"path":"a/b","depth":2,"type":"file"}
+`
+	if diff := cmp.Diff(ndjson.String(), wantNDJSON); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+
+	// Entries are classified by name, as there is no file to look at.
+	var buf bytes.Buffer
+	if err := Tree(".", WithColor(true), WithPathList(".", list), WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{colorLightBlue("src") + "/", colorLightMagenta("logo.png"), colorRed("a.tar.gz"), formatUnderLine(colorLightYellow("README.md"))} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTreeRenderer(t *testing.T) {
+	r := &recordRenderer{}
+
+	err := Tree(TMP_DIR+"/foo", rendererOption{r})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"root tmp/foo",
+		"entry tmp/foo/bar",
+		"enter tmp/foo/bar",
+		"entry tmp/foo/bar/baz",
+		"leave tmp/foo/bar",
+		"entry tmp/foo/quux",
+		"entry tmp/foo/qux",
+		"end tmp/foo",
+		"finish 1 3",
+	}
+	if diff := cmp.Diff(r.events, want); diff != "" {
+		t.Errorf("events missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestTreeWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Tree(TMP_DIR+"/foo", WithColor(false), WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `tmp/foo
+├── bar
+│   └── baz
+├── quux
+└── qux
+
+1 directories, 3 files
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
+
+func testCaseWithDate() (string, error) {
+	testCase := `tmp
+[90m├── [0m[[34m__DATETIME__[0m]  [94m01[0m/
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [4m[93mREADME.md[0m[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [33mcompiled.o[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [31mcompressed.zip[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [96mcrypto.asc[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [32mdocument.xlsx[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [92mexec[0m*
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [95mimage.png[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [35mmusic.mp3[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [90mtmp.bk[0m
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [35mvideo.mp4[0m
+[90m│   [0m[90m└── [0m[[34m__DATETIME__[0m]  [35mwav.wav[0m
+[90m├── [0m[[34m__DATETIME__[0m]  corge
+[90m├── [0m[[34m__DATETIME__[0m]  [94mfoo[0m/
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [94mbar[0m/
+[90m│   [0m[90m│   [0m[90m└── [0m[[34m__DATETIME__[0m]  baz
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  quux
+[90m│   [0m[90m└── [0m[[34m__DATETIME__[0m]  qux
+[90m├── [0m[[34m__DATETIME__[0m]  [94mgrault[0m/
+[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  [94mgarply[0m/
+[90m│   [0m[90m│   [0m[90m├── [0m[[34m__DATETIME__[0m]  fred
+[90m│   [0m[90m│   [0m[90m└── [0m[[34m__DATETIME__[0m]  [94mwaldo[0m/
+[90m│   [0m[90m│   [0m    [90m├── [0m[[34m__DATETIME__[0m]  wibble
+[90m│   [0m[90m│   [0m    [90m└── [0m[[34m__DATETIME__[0m]  wobble
+[90m│   [0m[90m└── [0m[[34m__DATETIME__[0m]  plugh
+[90m└── [0m[[34m__DATETIME__[0m]  [94mxyzzy[0m/
+    [90m└── [0m[[34m__DATETIME__[0m]  [94mthud[0m/
+        [90m├── [0m[[34m__DATETIME__[0m]  flob
+        [90m└── [0m[[34m__DATETIME__[0m]  wubble
+
+8 directories, 21 files`
+
+	modTime, err := modTime(TMP_DIR)
+	if err != nil {
+		return "", err
+	}
+
+	testCase = strings.Replace(testCase, "__DATETIME__", modTime, -1)
+
+	return testCase, nil
+}
+
+func modTime(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	modTime := fi.ModTime().Format("2006-01-02 15:04")
+
+	return modTime, nil
+}
@@ -0,0 +1,74 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Usage is the disk usage of a file or of a directory and all its contents.
+type Usage struct {
+	// Size is the apparent size in bytes.
+	Size int64
+	// Allocated is the number of bytes allocated on disk.
+	Allocated int64
+}
+
+func (u *Usage) add(o Usage) {
+	u.Size += o.Size
+	u.Allocated += o.Allocated
+}
+
+func fileUsage(fi os.FileInfo) Usage {
+	u := Usage{Size: fi.Size()}
+
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		u.Allocated = int64(stat.Blocks) * 512
+	}
+
+	return u
+}
+
+// isFirstLink reports whether fi is seen for the first time, so that files
+// with several hard links are counted once.
+func (w *walker) isFirstLink(fi os.FileInfo) bool {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || uint64(stat.Nlink) < 2 {
+		return true
+	}
+
+	id := [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}
+	if w.links[id] {
+		return false
+	}
+	w.links[id] = true
+
+	return true
+}
+
+// diskUsage returns the accumulated usage of dir, whose own information is fi.
+// The level limit does not apply, but filtered entries are not counted.
+// Followed links count as the links themselves, like at the top of the
+// walk, so that a link loop is not descended into forever.
+func (w *walker) diskUsage(dir string, fi os.FileInfo) Usage {
+	if u, ok := w.usages[dir]; ok {
+		return u
+	}
+
+	u := fileUsage(fi)
+
+	files, err := w.readDir(dir)
+	if err == nil {
+		for _, file := range files {
+			if file.IsDir() && !isFollowedLink(file) {
+				u.add(w.diskUsage(filepath.Join(dir, file.Name()), file))
+			} else if w.isFirstLink(file) {
+				u.add(fileUsage(file))
+			}
+		}
+	}
+
+	w.usages[dir] = u
+
+	return u
+}
@@ -0,0 +1,149 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// watchDebounce is how long Watch waits for more changes before rendering.
+	watchDebounce = 100 * time.Millisecond
+	// watchHighlight is how long changed entries stay highlighted.
+	watchHighlight = 3 * time.Second
+
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+// ErrWatchUnsupported is returned by Watch on platforms without a file
+// system notification backend.
+var ErrWatchUnsupported = errors.New("watch mode is not supported on this platform")
+
+// watcher reports the paths of changed entries below the watched roots.
+type watcher interface {
+	events() <-chan string
+	errors() <-chan error
+	close() error
+}
+
+type highlightOption map[string]bool
+
+func (h highlightOption) apply(w *walker) {
+	w.highlight = h
+}
+
+// Watch renders roots like Trees and renders them again whenever entries
+// below them are created, removed or modified, until ctx is done. Changed
+// entries are highlighted for a few seconds. Every rendering starts by
+// clearing the screen.
+func Watch(ctx context.Context, roots []string, opts ...Option) error {
+	out := newWalker(opts).out
+
+	wt, err := newWatcher(roots, opts)
+	if err != nil {
+		return err
+	}
+	defer wt.close()
+
+	changed := map[string]time.Time{}
+
+	render := func() error {
+		highlight := highlightOption{}
+		for p := range changed {
+			highlight[p] = true
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(clearScreen)
+
+		err := Trees(roots, append(opts, WithWriter(&buf), highlight)...)
+		if err != nil && !errors.Is(err, ErrPartial) {
+			return err
+		}
+
+		_, err = out.Write(buf.Bytes())
+		return err
+	}
+
+	if err := render(); err != nil {
+		return err
+	}
+
+	var debounce, expire <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-wt.errors():
+			return err
+		case p := <-wt.events():
+			changed[p] = time.Now()
+			if debounce == nil {
+				debounce = time.After(watchDebounce)
+			}
+			continue
+		case <-debounce:
+			debounce = nil
+		case <-expire:
+			expire = nil
+		}
+
+		var next time.Time
+		for p, t := range changed {
+			until := t.Add(watchHighlight)
+			if !until.After(time.Now()) {
+				delete(changed, p)
+			} else if next.IsZero() || until.Before(next) {
+				next = until
+			}
+		}
+
+		if err := render(); err != nil {
+			return err
+		}
+
+		if !next.IsZero() {
+			expire = time.After(time.Until(next))
+		}
+	}
+}
+
+// watchWalker returns a walker of root configured by opts, which selects
+// the directories to watch below root.
+func watchWalker(root string, opts []Option) (*walker, error) {
+	w := newWalker(opts)
+	w.root = root
+
+	if w.gitignore || len(w.ignoreFiles) > 0 {
+		if err := w.initIgnore(); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// watchesDir reports whether dir, at depth below the root, is to be watched:
+// the walk lists it, and its entries are within the level limit.
+func (w *walker) watchesDir(dir string, fi os.FileInfo, depth uint) bool {
+	// The disk usage and the pruning look below the level limit.
+	if depth >= w.level && !w.du && !w.prune {
+		return false
+	}
+
+	return depth == 0 || (fi.IsDir() && w.isListed(dir, fi))
+}
+
+// changedPath returns the path to highlight for an event on name in dir.
+// A removed entry is no longer listed, so its directory is highlighted instead.
+func changedPath(dir, name string, removed bool) string {
+	if removed || name == "" {
+		return dir
+	}
+
+	return filepath.Join(dir, name)
+}
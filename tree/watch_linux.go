@@ -0,0 +1,215 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// inotifyWatcher watches the directories listed below the roots with inotify
+// and adds watches for directories created later on.
+type inotifyWatcher struct {
+	file    *os.File
+	fd      int
+	mu      sync.Mutex
+	dirs    map[int32]watchedDir
+	eventCh chan string
+	errCh   chan error
+	done    chan struct{}
+	// warn receives the warning printed once the watch limit is reached.
+	warn    io.Writer
+	limited bool
+}
+
+// watchedDir is a watched directory at depth below its root, whose filter
+// is a walker of the root which selects the directories to watch below it.
+type watchedDir struct {
+	path   string
+	depth  uint
+	filter *walker
+}
+
+func newWatcher(roots []string, opts []Option) (watcher, error) {
+	// A non-blocking descriptor makes os.File use the runtime poller, so
+	// that close interrupts a pending read.
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w := &inotifyWatcher{
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		dirs:    map[int32]watchedDir{},
+		eventCh: make(chan string),
+		errCh:   make(chan error, 1),
+		done:    make(chan struct{}),
+		warn:    os.Stderr,
+	}
+
+	for _, root := range roots {
+		filter, err := watchWalker(root, opts)
+		if err == nil && filter.watchesDir(root, nil, 0) {
+			err = w.addTree(filter, root, 0)
+		}
+		if err != nil {
+			w.file.Close()
+			return nil, err
+		}
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// addTree watches dir, at depth below the root of filter, and the
+// directories below it which filter lists. Directories which disappear or
+// cannot be read on the way are skipped, and so is everything left once the
+// watch limit is reached.
+func (w *inotifyWatcher) addTree(filter *walker, dir string, depth uint) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		switch {
+		case os.IsNotExist(err) || err == syscall.EACCES:
+			return nil
+		case err == syscall.ENOSPC:
+			w.warnLimit(dir)
+			return nil
+		}
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+
+	// A directory reached again through a followed link keeps its watch,
+	// which also ends link loops.
+	w.mu.Lock()
+	_, seen := w.dirs[int32(wd)]
+	if !seen {
+		w.dirs[int32(wd)] = watchedDir{path: dir, depth: depth, filter: filter}
+	}
+	w.mu.Unlock()
+
+	if seen {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, fi := range files {
+		if err := w.addEntry(filter, filepath.Join(dir, fi.Name()), fi, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addEntry watches the tree of path, whose information is fi, at depth below
+// the root of filter if filter lists it as a directory.
+func (w *inotifyWatcher) addEntry(filter *walker, path string, fi os.FileInfo, depth uint) error {
+	fi = filter.followLink(path, fi)
+	if !filter.watchesDir(path, fi, depth) {
+		return nil
+	}
+
+	return w.addTree(filter, path, depth)
+}
+
+// warnLimit tells once that the inotify watch limit is reached at dir, so
+// that changes below it are not shown.
+func (w *inotifyWatcher) warnLimit(dir string) {
+	if w.limited {
+		return
+	}
+	w.limited = true
+
+	fmt.Fprintf(w.warn, "gotree: inotify watch limit reached at %s, some changes will not be shown (see fs.inotify.max_user_watches)\n", dir)
+}
+
+func (w *inotifyWatcher) run() {
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			w.fail(err)
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+
+			w.mu.Lock()
+			d, ok := w.dirs[ev.Wd]
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, ev.Wd)
+			}
+			w.mu.Unlock()
+
+			if !ok || ev.Mask&syscall.IN_IGNORED != 0 {
+				continue
+			}
+
+			// Links are created as files, but may lead to directories to follow.
+			added := ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0
+			if added && (ev.Mask&syscall.IN_ISDIR != 0 || d.filter.follow) {
+				path := filepath.Join(d.path, name)
+				if fi, err := os.Lstat(path); err == nil {
+					if err := w.addEntry(d.filter, path, fi, d.depth+1); err != nil {
+						w.fail(err)
+						return
+					}
+				}
+			}
+
+			removed := ev.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM|syscall.IN_DELETE_SELF) != 0
+			if ev.Mask&syscall.IN_DELETE_SELF != 0 {
+				name = ""
+			}
+
+			select {
+			case w.eventCh <- changedPath(d.path, name, removed):
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// fail reports err unless the watcher was closed, which also fails reads.
+func (w *inotifyWatcher) fail(err error) {
+	select {
+	case <-w.done:
+	default:
+		w.errCh <- err
+	}
+}
+
+func (w *inotifyWatcher) events() <-chan string {
+	return w.eventCh
+}
+
+func (w *inotifyWatcher) errors() <-chan error {
+	return w.errCh
+}
+
+func (w *inotifyWatcher) close() error {
+	close(w.done)
+	return w.file.Close()
+}
@@ -0,0 +1,169 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out lockedBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, []string{dir}, WithColor(false), WithWriter(&out))
+	}()
+
+	waitFor := func(want string) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("output does not contain %q:\n%s", want, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("1 directories, 0 files")
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "sub", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(clearScreen + dir + "\n└── sub\n    └── file  [changed]\n\n1 directories, 1 files\n")
+
+	// Directories created after the start are watched too.
+	if err := os.MkdirAll(filepath.Join(dir, "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * watchDebounce)
+	if err := ioutil.WriteFile(filepath.Join(dir, "new", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("├── new  [changed]\n│   └── file  [changed]")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch returned %v", err)
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{"a/b/c", ".hidden", "skipped", "ignored"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".gotreeignore"), []byte("ignored/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+		opts []Option
+	}{
+		{
+			name: "gotree --watch <directory>",
+			want: []string{".", "a", "a/b", "a/b/c", "ignored", "skipped"},
+		},
+		{
+			name: "gotree --watch -a -L 2 <directory>",
+			want: []string{".", ".hidden", "a", "ignored", "skipped"},
+			opts: []Option{WithIncludeDot(true), WithLevel(2)},
+		},
+		{
+			name: "gotree --watch -I skipped --ignore-file .gotreeignore <directory>",
+			want: []string{".", "a", "a/b", "a/b/c"},
+			opts: []Option{WithIgnorePattern("skipped"), WithIgnoreFile(".gotreeignore")},
+		},
+		{
+			name: "gotree --watch -L 0 <directory>",
+			want: nil,
+			opts: []Option{WithLevel(0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wt, err := newWatcher([]string{dir}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer wt.close()
+
+			var got []string
+			for _, d := range wt.(*inotifyWatcher).dirs {
+				rel, err := filepath.Rel(dir, d.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, rel)
+			}
+			sort.Strings(got)
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("watched directories missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWatchLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w := &inotifyWatcher{warn: &buf}
+
+	w.warnLimit("a")
+	w.warnLimit("b")
+
+	if got := strings.Count(buf.String(), "\n"); got != 1 || !strings.Contains(buf.String(), "limit reached at a") {
+		t.Errorf("warnLimit printed %q, want a single warning about a", buf.String())
+	}
+}
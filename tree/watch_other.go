@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package tree
+
+func newWatcher(roots []string, opts []Option) (watcher, error) {
+	return nil, ErrWatchUnsupported
+}
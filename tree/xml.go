@@ -0,0 +1,280 @@
+package tree
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// xmlRenderer writes the tree as an XML document in the schema of GNU tree -X.
+// Every entry is an element named after its type, nested in its directory.
+type xmlRenderer struct {
+	out     io.Writer
+	started bool
+	depth   int
+	pending *Row
+}
+
+// NewXMLRenderer returns a renderer which writes the tree to out as an XML
+// document compatible with the output of tree -X.
+func NewXMLRenderer(out io.Writer) Renderer {
+	return &xmlRenderer{out: out}
+}
+
+func (x *xmlRenderer) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+
+	_, err := io.WriteString(x.out, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n")
+	return err
+}
+
+func (x *xmlRenderer) indent() string {
+	return strings.Repeat("  ", x.depth)
+}
+
+// flush writes the directory entry held back by Entry as an empty element,
+// as it was not descended into.
+func (x *xmlRenderer) flush() error {
+	if x.pending == nil {
+		return nil
+	}
+
+	row := x.pending
+	x.pending = nil
+
+	return x.leaf(row)
+}
+
+func (x *xmlRenderer) leaf(row *Row) error {
+	tag := fileType(row.mode)
+	x.depth++
+	body := x.content(row)
+	x.depth--
+
+	if body != "" {
+		body = "\n" + body + x.indent()
+	}
+
+	_, err := fmt.Fprintf(x.out, "%s<%s%s>%s</%s>\n", x.indent(), tag, x.attrs(row, row.displayName()), body, tag)
+	return err
+}
+
+func (x *xmlRenderer) BeginRoot(root Row) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	name := root.path
+	if root.absolute {
+		name = root.displayName()
+	}
+
+	attrs := xmlAttr("name", name)
+	if root.name != "" {
+		attrs = x.attrs(&root, name)
+	}
+
+	x.depth = 1
+	if _, err := fmt.Fprintf(x.out, "%s<directory%s>\n", x.indent(), attrs); err != nil {
+		return err
+	}
+	x.depth++
+
+	_, err := io.WriteString(x.out, x.content(&root))
+	return err
+}
+
+func (x *xmlRenderer) EnterDir(row Row) error {
+	x.pending = nil
+
+	tag := fileType(row.mode)
+	if _, err := fmt.Fprintf(x.out, "%s<%s%s>\n", x.indent(), tag, x.attrs(&row, row.displayName())); err != nil {
+		return err
+	}
+	x.depth++
+
+	_, err := io.WriteString(x.out, x.content(&row))
+	return err
+}
+
+func (x *xmlRenderer) Entry(row Row) error {
+	if err := x.flush(); err != nil {
+		return err
+	}
+
+	// A directory is written once it is known whether it is descended into.
+	if row.isDir() {
+		x.pending = &row
+		return nil
+	}
+
+	return x.leaf(&row)
+}
+
+func (x *xmlRenderer) LeaveDir(row Row) error {
+	if err := x.flush(); err != nil {
+		return err
+	}
+
+	x.depth--
+
+	_, err := fmt.Fprintf(x.out, "%s</%s>\n", x.indent(), fileType(row.mode))
+	return err
+}
+
+func (x *xmlRenderer) EndRoot(root Row) error {
+	if err := x.flush(); err != nil {
+		return err
+	}
+
+	x.depth--
+
+	_, err := fmt.Fprintf(x.out, "%s</directory>\n", x.indent())
+	return err
+}
+
+func (x *xmlRenderer) Finish(result Result) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	if result.NoReport {
+		_, err := io.WriteString(x.out, "</tree>\n")
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("  <report>\n")
+	if result.Usage != nil {
+		fmt.Fprintf(&b, "    <size>%d</size>\n", result.Usage.Size)
+	}
+	fmt.Fprintf(&b, "    <directories>%d</directories>\n", result.Directories)
+	fmt.Fprintf(&b, "    <files>%d</files>\n", result.Files)
+	if result.Errors > 0 {
+		fmt.Fprintf(&b, "    <errors>%d</errors>\n", result.Errors)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(&b, "    <skipped>%d</skipped>\n", result.Skipped)
+	}
+	b.WriteString("  </report>\n</tree>\n")
+
+	_, err := io.WriteString(x.out, b.String())
+	return err
+}
+
+// attrs returns the attributes of the element of a row: name and link target,
+// then the metadata which GNU tree -X adds for -p, -u, -g, -s and -D.
+func (x *xmlRenderer) attrs(row *Row, name string) string {
+	var b strings.Builder
+	b.WriteString(xmlAttr("name", name))
+
+	if row.isLink() {
+		b.WriteString(xmlAttr("target", row.linkTarget))
+	}
+
+	if row.permission {
+		b.WriteString(xmlAttr("mode", fmt.Sprintf("%04o", unixPerm(row.mode))))
+		b.WriteString(xmlAttr("prot", xmlProt(row.mode)))
+	}
+
+	if row.uid {
+		b.WriteString(xmlAttr("user", row.userName()))
+	}
+
+	if row.gid {
+		b.WriteString(xmlAttr("group", row.groupName()))
+	}
+
+	if row.usage != nil {
+		b.WriteString(xmlAttr("size", fmt.Sprint(row.usage.Size)))
+	} else if row.size {
+		b.WriteString(xmlAttr("size", fmt.Sprint(row.byteSize())))
+	}
+
+	if row.datetime {
+		plain := *row
+		plain.colored = false
+		b.WriteString(xmlAttr("time", plain.Datetime()))
+	}
+
+	return b.String()
+}
+
+// xmlProt returns the file type and permissions of m as ls -l and GNU tree
+// print them, such as -rw-r--r-- or drwxr-sr-x.
+func xmlProt(m os.FileMode) string {
+	prot := []byte("-rwxrwxrwx")
+
+	switch {
+	case m.IsDir():
+		prot[0] = 'd'
+	case m&os.ModeSymlink != 0:
+		prot[0] = 'l'
+	case m&os.ModeNamedPipe != 0:
+		prot[0] = 'p'
+	case m&os.ModeSocket != 0:
+		prot[0] = 's'
+	case m&os.ModeCharDevice != 0:
+		prot[0] = 'c'
+	case m&os.ModeDevice != 0:
+		prot[0] = 'b'
+	}
+
+	for i := 0; i < 9; i++ {
+		if m&(1<<uint(8-i)) == 0 {
+			prot[i+1] = '-'
+		}
+	}
+
+	special := []struct {
+		mode os.FileMode
+		pos  int
+		c    byte
+	}{
+		{os.ModeSetuid, 3, 's'},
+		{os.ModeSetgid, 6, 's'},
+		{os.ModeSticky, 9, 't'},
+	}
+	for _, sp := range special {
+		if m&sp.mode == 0 {
+			continue
+		}
+
+		// The letter is upper case if the execute bit is not set.
+		if prot[sp.pos] == '-' {
+			prot[sp.pos] = sp.c - 'a' + 'A'
+		} else {
+			prot[sp.pos] = sp.c
+		}
+	}
+
+	return string(prot)
+}
+
+// content returns the error elements inside the element of a row.
+func (x *xmlRenderer) content(row *Row) string {
+	var b strings.Builder
+
+	if row.err != nil {
+		fmt.Fprintf(&b, "%s<error>error opening dir</error>\n", x.indent())
+	}
+
+	if row.exceeded > 0 {
+		fmt.Fprintf(&b, "%s<error>%d entries exceeds filelimit, not opening dir</error>\n", x.indent(), row.exceeded)
+	}
+
+	return b.String()
+}
+
+// xmlAttr returns an attribute with its value escaped, preceded by a space.
+func xmlAttr(name, value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+
+	return fmt.Sprintf(` %s="%s"`, name, b.String())
+}
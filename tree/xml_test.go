@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXMLProt(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0644, "-rw-r--r--"},
+		{os.ModeDir | 0755, "drwxr-xr-x"},
+		{os.ModeSymlink | 0777, "lrwxrwxrwx"},
+		{os.ModeNamedPipe | 0600, "prw-------"},
+		{os.ModeSocket | 0755, "srwxr-xr-x"},
+		{os.ModeDevice | os.ModeCharDevice | 0666, "crw-rw-rw-"},
+		{os.ModeDevice | 0660, "brw-rw----"},
+		{os.ModeSetuid | 0755, "-rwsr-xr-x"},
+		{os.ModeSetuid | 0644, "-rwSr--r--"},
+		{os.ModeDir | os.ModeSetgid | 0775, "drwxrwsr-x"},
+		{os.ModeDir | os.ModeSticky | 0777, "drwxrwxrwt"},
+		{os.ModeDir | os.ModeSticky | 0776, "drwxrwxrwT"},
+	}
+
+	for _, tt := range tests {
+		if got := xmlProt(tt.mode); got != tt.want {
+			t.Errorf("xmlProt(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestXMLAttrs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(file, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Date(2020, 5, 17, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("file", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	row := Row{}
+	row.setFile(mustLstat(t, file))
+	user, group := row.userName(), row.groupName()
+
+	tests := []struct {
+		name string
+		want []string
+		opts []Option
+	}{
+		{
+			name: "gotree -X <directory>",
+			want: []string{
+				`<file name="file"></file>`,
+				`<link name="link" target="file"></link>`,
+			},
+		},
+		{
+			name: "gotree -X -p <directory>",
+			want: []string{
+				`<file name="file" mode="0640" prot="-rw-r-----"></file>`,
+				`<link name="link" target="file" mode="0777" prot="lrwxrwxrwx"></link>`,
+			},
+			opts: []Option{WithPermission(true)},
+		},
+		{
+			name: "gotree -X -u -g <directory>",
+			want: []string{
+				fmt.Sprintf(`<file name="file" user="%s" group="%s"></file>`, user, group),
+			},
+			opts: []Option{WithUID(true), WithGID(true)},
+		},
+		{
+			name: "gotree -X -s -D <directory>",
+			want: []string{
+				`<file name="file" size="5" time="2020-05-17 09:30"></file>`,
+			},
+			opts: []Option{WithSize(true), WithDatetime(true)},
+		},
+		{
+			name: "gotree -X -p -u -g -s -D <directory>",
+			want: []string{
+				fmt.Sprintf(`<file name="file" mode="0640" prot="-rw-r-----" user="%s" group="%s" size="5" time="2020-05-17 09:30"></file>`, user, group),
+			},
+			opts: []Option{WithPermission(true), WithUID(true), WithGID(true), WithSize(true), WithDatetime(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append(tt.opts, WithColor(false), WithFormat(OutputXML), WithWriter(&buf))
+			if err := Tree(dir, opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output does not contain %s:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
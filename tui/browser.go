@@ -0,0 +1,313 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Keys which are not plain characters.
+const (
+	keyUp = iota + 0x110000
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyInterrupt
+)
+
+// browser is the state of the interactive view, independent of the terminal.
+type browser struct {
+	roots     []*node
+	summary   string
+	cursor    int
+	top       int
+	height    int
+	searching bool
+	query     string
+	selected  string
+	done      bool
+}
+
+func newBrowser(c *collector) *browser {
+	return &browser{roots: c.roots, summary: c.result.String(), height: 24}
+}
+
+// visible returns the nodes which are not inside a collapsed directory, in tree order.
+func (b *browser) visible() []*node {
+	var nodes []*node
+
+	var add func(n *node)
+	add = func(n *node) {
+		nodes = append(nodes, n)
+		if n.expanded {
+			for _, c := range n.children {
+				add(c)
+			}
+		}
+	}
+
+	for _, r := range b.roots {
+		add(r)
+	}
+
+	return nodes
+}
+
+// all returns every node in tree order.
+func (b *browser) all() []*node {
+	var nodes []*node
+
+	var add func(n *node)
+	add = func(n *node) {
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			add(c)
+		}
+	}
+
+	for _, r := range b.roots {
+		add(r)
+	}
+
+	return nodes
+}
+
+func (b *browser) current() *node {
+	nodes := b.visible()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	return nodes[b.cursor]
+}
+
+// moveTo places the cursor on n, expanding its parents.
+func (b *browser) moveTo(n *node) {
+	for p := n.parent; p != nil; p = p.parent {
+		p.expanded = true
+	}
+
+	for i, v := range b.visible() {
+		if v == n {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+
+	if last := len(b.visible()) - 1; b.cursor > last {
+		b.cursor = last
+	}
+
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// search moves to the next node after the cursor whose name contains the
+// query, case-insensitively. The search starts at the cursor itself unless
+// next is set, and goes backwards if backward is set.
+func (b *browser) search(next, backward bool) bool {
+	if b.query == "" {
+		return false
+	}
+
+	nodes := b.all()
+	cur := b.current()
+
+	start := 0
+	for i, n := range nodes {
+		if n == cur {
+			start = i
+		}
+	}
+
+	query := strings.ToLower(b.query)
+	for i := 0; i < len(nodes); i++ {
+		var j int
+		switch {
+		case backward:
+			j = (start - 1 - i + 2*len(nodes)) % len(nodes)
+		case next:
+			j = (start + 1 + i) % len(nodes)
+		default:
+			j = (start + i) % len(nodes)
+		}
+
+		n := nodes[j]
+		if strings.Contains(strings.ToLower(n.name()), query) {
+			b.moveTo(n)
+			return true
+		}
+	}
+
+	return false
+}
+
+// handle applies a key press.
+func (b *browser) handle(key rune) {
+	if b.searching {
+		switch key {
+		case keyEnter:
+			b.searching = false
+		case keyEscape, keyInterrupt:
+			b.searching = false
+			b.query = ""
+		case keyBackspace:
+			if r := []rune(b.query); len(r) > 0 {
+				b.query = string(r[:len(r)-1])
+			}
+			b.search(false, false)
+		default:
+			if key >= ' ' && key < keyUp {
+				b.query += string(key)
+				b.search(false, false)
+			}
+		}
+		return
+	}
+
+	switch key {
+	case keyUp, 'k':
+		b.move(-1)
+	case keyDown, 'j':
+		b.move(1)
+	case keyPageUp:
+		b.move(-b.pageSize())
+	case keyPageDown:
+		b.move(b.pageSize())
+	case keyHome, 'g':
+		b.cursor = 0
+	case keyEnd, 'G':
+		b.move(len(b.visible()))
+	case keyRight, 'l':
+		if n := b.current(); n != nil {
+			if n.expanded && len(n.children) > 0 {
+				b.move(1)
+			}
+			n.expanded = true
+		}
+	case keyLeft, 'h':
+		if n := b.current(); n != nil {
+			if n.expanded && len(n.children) > 0 && !n.root {
+				n.expanded = false
+			} else if n.parent != nil {
+				b.moveTo(n.parent)
+			}
+		}
+	case ' ', '\t':
+		if n := b.current(); n != nil && !n.root {
+			n.expanded = !n.expanded
+		}
+	case '/':
+		b.searching = true
+		b.query = ""
+	case 'n':
+		b.search(true, false)
+	case 'N':
+		b.search(false, true)
+	case keyEnter:
+		if n := b.current(); n != nil {
+			b.selected = n.row.Path()
+		}
+		b.done = true
+	case 'q', keyEscape, keyInterrupt:
+		b.done = true
+	}
+}
+
+func (b *browser) pageSize() int {
+	if h := b.height - 2; h > 1 {
+		return h
+	}
+
+	return 1
+}
+
+// view returns the lines of the screen: the visible part of the tree followed
+// by a status line.
+func (b *browser) view() []string {
+	nodes := b.visible()
+	rows := b.height - 1
+	if rows < 1 {
+		rows = 1
+	}
+
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+rows {
+		b.top = b.cursor - rows + 1
+	}
+
+	var lines []string
+	for i := b.top; i < len(nodes) && i < b.top+rows; i++ {
+		line := nodes[i].line()
+		if i == b.cursor {
+			line = highlight("> " + line)
+		} else {
+			line = "  " + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+
+	return append(lines, b.status())
+}
+
+// highlight shows line in reverse video, reapplied after every reset inside it.
+func highlight(line string) string {
+	return "\x1b[7m" + strings.Replace(line, "\x1b[0m", "\x1b[0m\x1b[7m", -1) + "\x1b[0m"
+}
+
+func (b *browser) status() string {
+	if b.searching {
+		return "/" + b.query
+	}
+
+	path := ""
+	if n := b.current(); n != nil {
+		path = n.row.Path()
+	}
+
+	return fmt.Sprintf("%s  (%s; enter: select, /: search, q: quit)", path, b.summary)
+}
+
+// line returns the text of the node as the tree command prints it, with the
+// number of hidden entries of a collapsed directory.
+func (n *node) line() string {
+	var line string
+	if n.root {
+		line = n.row.Root()
+	} else {
+		line = n.row.Str()
+	}
+
+	if !n.expanded && len(n.children) > 0 {
+		line += fmt.Sprintf("  [+%d]", len(n.children))
+	}
+
+	return line
+}
+
+func (n *node) name() string {
+	if n.root {
+		return n.row.Path()
+	}
+
+	return filepath.Base(n.row.Path())
+}
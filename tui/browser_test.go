@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Raita876/gotree/tree"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestBrowser(t *testing.T) (*browser, string) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "gotree")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"a/b/deep.txt", "a/c.txt", "d/e.txt", "f.txt"} {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &collector{}
+	if err := tree.Tree(dir, tree.WithColor(false), tree.WithRenderer(c)); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBrowser(c)
+	b.height = 8
+
+	return b, dir
+}
+
+func TestBrowser(t *testing.T) {
+	b, dir := newTestBrowser(t)
+	defer os.RemoveAll(dir)
+
+	status := "  (3 directories, 4 files; enter: select, /: search, q: quit)"
+
+	tests := []struct {
+		name string
+		keys []rune
+		want []string
+	}{
+		{
+			name: "collapsed",
+			keys: nil,
+			want: []string{
+				"> " + dir,
+				"  ├── a  [+2]",
+				"  ├── d  [+1]",
+				"  └── f.txt",
+				"",
+				"",
+				"",
+				dir + status,
+			},
+		},
+		{
+			name: "expand",
+			keys: []rune{keyDown, keyRight, keyRight},
+			want: []string{
+				"  " + dir,
+				"  ├── a",
+				"> │   ├── b  [+1]",
+				"  │   └── c.txt",
+				"  ├── d  [+1]",
+				"  └── f.txt",
+				"",
+				filepath.Join(dir, "a", "b") + status,
+			},
+		},
+		{
+			name: "collapse parent",
+			keys: []rune{keyLeft, keyLeft},
+			want: []string{
+				"  " + dir,
+				"> ├── a  [+2]",
+				"  ├── d  [+1]",
+				"  └── f.txt",
+				"",
+				"",
+				"",
+				filepath.Join(dir, "a") + status,
+			},
+		},
+		{
+			name: "search",
+			keys: []rune{'/', 'D', 'e'},
+			want: []string{
+				"  " + dir,
+				"  ├── a",
+				"  │   ├── b",
+				"> │   │   └── deep.txt",
+				"  │   └── c.txt",
+				"  ├── d  [+1]",
+				"  └── f.txt",
+				"/De",
+			},
+		},
+		{
+			name: "next match",
+			keys: []rune{keyEnter, '/', 't', 'x', 't', keyEnter, 'n', 'n'},
+			want: []string{
+				"  " + dir,
+				"  ├── a",
+				"  │   ├── b",
+				"  │   │   └── deep.txt",
+				"  │   └── c.txt",
+				"  ├── d",
+				"> │   └── e.txt",
+				filepath.Join(dir, "d", "e.txt") + status,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range tt.keys {
+				b.handle(k)
+			}
+
+			got := b.view()
+			for i := range got {
+				if len(got[i]) > 0 && got[i][0] == '\x1b' {
+					got[i] = got[i][len("\x1b[7m") : len(got[i])-len("\x1b[0m")]
+				}
+			}
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("output missmatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+
+	b.handle(keyEnter)
+	if !b.done || b.selected != filepath.Join(dir, "d", "e.txt") {
+		t.Errorf("got done %v and selected %q after enter", b.done, b.selected)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[6~\x1bé\r\x7f"))
+	want := []rune{'j', keyUp, keyPageDown, keyEscape, 'é', keyEnter, keyBackspace}
+
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("output missmatch (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,54 @@
+package tui
+
+import "github.com/Raita876/gotree/tree"
+
+// node is an entry of the browsed hierarchy.
+type node struct {
+	row      tree.Row
+	root     bool
+	parent   *node
+	children []*node
+	expanded bool
+}
+
+// collector is a tree.Renderer building the hierarchy in memory.
+type collector struct {
+	roots   []*node
+	current *node
+	last    *node
+	result  tree.Result
+}
+
+func (c *collector) BeginRoot(root tree.Row) error {
+	n := &node{row: root, root: true, expanded: true}
+	c.roots = append(c.roots, n)
+	c.current = n
+	return nil
+}
+
+func (c *collector) EnterDir(row tree.Row) error {
+	c.current = c.last
+	return nil
+}
+
+func (c *collector) Entry(row tree.Row) error {
+	n := &node{row: row, parent: c.current}
+	c.current.children = append(c.current.children, n)
+	c.last = n
+	return nil
+}
+
+func (c *collector) LeaveDir(row tree.Row) error {
+	c.current = c.current.parent
+	return nil
+}
+
+func (c *collector) EndRoot(root tree.Row) error {
+	c.current = nil
+	return nil
+}
+
+func (c *collector) Finish(result tree.Result) error {
+	c.result = result
+	return nil
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+var escapeKeys = map[string]rune{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1b[C":  keyRight,
+	"\x1b[D":  keyLeft,
+	"\x1bOA":  keyUp,
+	"\x1bOB":  keyDown,
+	"\x1bOC":  keyRight,
+	"\x1bOD":  keyLeft,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+	"\x1b[H":  keyHome,
+	"\x1b[F":  keyEnd,
+	"\x1b[1~": keyHome,
+	"\x1b[4~": keyEnd,
+	"\x1bOH":  keyHome,
+	"\x1bOF":  keyEnd,
+}
+
+// parseKeys splits the bytes of a terminal read into key presses. Escape
+// sequences are expected to arrive in a single read; a lone escape byte is
+// the escape key.
+func parseKeys(in []byte) []rune {
+	var keys []rune
+
+	s := string(in)
+	for len(s) > 0 {
+		if s[0] == 0x1b {
+			matched := false
+			for seq, key := range escapeKeys {
+				if strings.HasPrefix(s, seq) {
+					keys = append(keys, key)
+					s = s[len(seq):]
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+
+			keys = append(keys, keyEscape)
+			s = s[1:]
+			continue
+		}
+
+		switch s[0] {
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case 0x7f, 0x08:
+			keys = append(keys, keyBackspace)
+		case 0x03:
+			keys = append(keys, keyInterrupt)
+		default:
+			r, size := utf8.DecodeRuneInString(s)
+			keys = append(keys, r)
+			s = s[size:]
+			continue
+		}
+		s = s[1:]
+	}
+
+	return keys
+}
@@ -0,0 +1,101 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package tui
+
+import (
+	"bufio"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[?7l"
+	leaveScreen = "\x1b[?7h\x1b[?25h\x1b[?1049l"
+)
+
+// run shows b on the controlling terminal until it is done. The terminal is
+// used instead of stdin and stdout, so that the selection can be captured
+// by the shell.
+func run(b *browser) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return err
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return err
+	}
+	defer unix.IoctlSetTermios(fd, ioctlSetTermios, saved)
+
+	out := bufio.NewWriter(tty)
+	out.WriteString(enterScreen)
+	defer func() {
+		out.WriteString(leaveScreen)
+		out.Flush()
+	}()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	keys := make(chan []byte)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			buf := make([]byte, 64)
+			n, err := tty.Read(buf)
+			if err != nil {
+				errs <- err
+				return
+			}
+			keys <- buf[:n]
+		}
+	}()
+
+	for !b.done {
+		if ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil && ws.Row > 0 {
+			b.height = int(ws.Row)
+		}
+
+		out.WriteString("\x1b[H")
+		out.WriteString(strings.Join(b.view(), "\x1b[K\r\n"))
+		out.WriteString("\x1b[K")
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case in := <-keys:
+			for _, key := range parseKeys(in) {
+				b.handle(key)
+			}
+		case <-winch:
+		case err := <-errs:
+			return err
+		}
+	}
+
+	return nil
+}
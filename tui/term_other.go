@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package tui
+
+import "errors"
+
+func run(b *browser) error {
+	return errors.New("interactive mode is not supported on this platform")
+}
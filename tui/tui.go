@@ -0,0 +1,30 @@
+// Package tui browses the output of the tree package in a full-screen
+// terminal view, where directories can be collapsed and expanded and entries
+// searched incrementally.
+package tui
+
+import (
+	"errors"
+
+	"github.com/Raita876/gotree/tree"
+)
+
+// Browse walks roots like tree.Trees and lets the user browse the result on
+// the terminal. It returns the path of the entry selected with enter, or an
+// empty string if the user quit.
+func Browse(roots []string, opts ...tree.Option) (string, error) {
+	c := &collector{}
+
+	err := tree.Trees(roots, append(opts, tree.WithRenderer(c))...)
+	if err != nil && !errors.Is(err, tree.ErrPartial) {
+		return "", err
+	}
+
+	b := newBrowser(c)
+
+	if err := run(b); err != nil {
+		return "", err
+	}
+
+	return b.selected, nil
+}